@@ -1,11 +1,17 @@
 package ghost
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"net/http"
 	"net/url"
+	"time"
 
 	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/go-cleanhttp"
 )
 
 // Config defines the configuration options for the Ghost client
@@ -13,6 +19,19 @@ type Config struct {
 	User     string
 	Password string
 	URL      string
+
+	// HTTP transport settings
+	RequestTimeout time.Duration
+	CAFile         string
+	CAPEM          string
+	Insecure       bool
+	ClientCert     string
+	ClientKey      string
+	ProxyURL       string
+
+	// Retries of requests failing with a transient error
+	MaxRetries   int
+	RetryMaxWait time.Duration
 }
 
 // Client returns a new Ghost client
@@ -26,9 +45,77 @@ func (c *Config) Client() (*ghost.Client, error) {
 		return nil, fmt.Errorf("Invalid endpoint URL")
 	}
 
-	client := ghost.NewClient(c.URL, c.User, c.Password)
+	httpClient, err := c.httpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	client := ghost.NewClientWithHTTPClient(c.URL, c.User, c.Password, httpClient)
+	client.MaxRetries = c.MaxRetries
+	if c.RetryMaxWait > 0 {
+		client.RetryMaxWait = c.RetryMaxWait
+	}
 
 	log.Printf("[INFO] Ghost client configured: %s %s", c.User, c.URL)
 
 	return client, nil
 }
+
+// Build the HTTP client used by the Ghost client from the transport settings
+func (c *Config) httpClient() (*http.Client, error) {
+	transport := cleanhttp.DefaultPooledTransport()
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: c.Insecure,
+	}
+
+	if c.CAFile != "" && c.CAPEM != "" {
+		return nil, fmt.Errorf("Only one of ca_file and ca_pem can be set")
+	}
+
+	caPEM := []byte(c.CAPEM)
+	if c.CAFile != "" {
+		data, err := ioutil.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading CA file: %v", err)
+		}
+		caPEM = data
+	}
+	if len(caPEM) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("No valid PEM certificate found in CA bundle")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if (c.ClientCert == "") != (c.ClientKey == "") {
+		return nil, fmt.Errorf("client_cert and client_key must be set together")
+	}
+	if c.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(c.ClientCert, c.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("Error loading client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.ProxyURL != "" {
+		proxyURL, err := url.Parse(c.ProxyURL)
+		if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return nil, fmt.Errorf("Invalid proxy URL: %s", c.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	timeout := c.RequestTimeout
+	if timeout == 0 {
+		timeout = ghost.DefaultTimeout
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+	}, nil
+}
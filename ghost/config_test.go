@@ -1,7 +1,10 @@
 package ghost
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 // Test config with empty parameters
@@ -42,3 +45,57 @@ func TestConfigValidParameters(t *testing.T) {
 		t.Fatalf("expected no error, but got %s", err)
 	}
 }
+
+// Test config with invalid transport parameters
+func TestConfigInvalidTransportParameters(t *testing.T) {
+	cases := []Config{
+		{CAFile: "/non/existent/ca.pem"},
+		{CAPEM: "not a certificate"},
+		{CAFile: "/non/existent/ca.pem", CAPEM: "not a certificate"},
+		{ClientCert: "/non/existent/cert.pem"},
+		{ClientCert: "/non/existent/cert.pem", ClientKey: "/non/existent/key.pem"},
+		{ProxyURL: "proxy:3128"},
+	}
+
+	for _, config := range cases {
+		config.User = "myuser"
+		config.Password = "mypwd"
+		config.URL = "https://www.valid.url"
+
+		if _, err := config.Client(); err == nil {
+			t.Fatalf("expected error for %#v, but got nil", config)
+		}
+	}
+}
+
+// Test config with valid transport parameters
+func TestConfigValidTransportParameters(t *testing.T) {
+	config := Config{
+		User:           "myuser",
+		Password:       "mypwd",
+		URL:            "https://www.valid.url",
+		RequestTimeout: 30 * time.Second,
+		Insecure:       true,
+		ProxyURL:       "http://proxy:3128",
+	}
+
+	client, err := config.Client()
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+
+	if client.HTTPClient.Timeout != config.RequestTimeout {
+		t.Fatalf("Unexpected request timeout.\nExpected: %v\nGiven:    %v",
+			config.RequestTimeout, client.HTTPClient.Timeout)
+	}
+
+	transport := client.HTTPClient.Transport.(*http.Transport)
+	if !transport.TLSClientConfig.InsecureSkipVerify {
+		t.Fatalf("expected TLS verification to be skipped")
+	}
+
+	proxy, err := transport.Proxy(httptest.NewRequest("GET", config.URL, nil))
+	if err != nil || proxy.String() != config.ProxyURL {
+		t.Fatalf("Unexpected proxy.\nExpected: %v\nGiven:    %v (%v)", config.ProxyURL, proxy, err)
+	}
+}
@@ -0,0 +1,83 @@
+package ghost
+
+import (
+	"fmt"
+	"log"
+
+	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceGhostApp() *schema.Resource {
+	dataSchema := dataSourceSchemaFromResourceSchema(resourceGhostApp().Schema)
+
+	// Lookup arguments: either the app ID or its name, env and role
+	dataSchema["id"] = &schema.Schema{
+		Type:          schema.TypeString,
+		Optional:      true,
+		Computed:      true,
+		ValidateFunc:  MatchesRegexp(`^[a-f0-9]{24}$`),
+		ConflictsWith: []string{"name", "env", "role"},
+	}
+	for _, key := range []string{"name", "env", "role"} {
+		dataSchema[key].Optional = true
+		dataSchema[key].ConflictsWith = []string{"id"}
+	}
+
+	return &schema.Resource{
+		Read:   dataSourceGhostAppRead,
+		Schema: dataSchema,
+	}
+}
+
+func dataSourceGhostAppRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(lookupTimeout)
+	defer cancel()
+
+	var app ghost.App
+
+	if id, ok := d.GetOk("id"); ok {
+		log.Printf("[INFO] Reading Ghost app %s", id.(string))
+
+		var err error
+		app, err = client.GetAppWithContext(ctx, id.(string))
+		if err != nil {
+			return fmt.Errorf("[ERROR] error reading Ghost app %s: %v", id.(string), err)
+		}
+	} else {
+		name := d.Get("name").(string)
+		env := d.Get("env").(string)
+		role := d.Get("role").(string)
+		if name == "" || env == "" || role == "" {
+			return fmt.Errorf("[ERROR] either id or all of name, env and role must be set")
+		}
+
+		log.Printf("[INFO] Looking up Ghost app %s/%s/%s", name, env, role)
+
+		apps, err := client.ListAppsWithContext(ctx, &ghost.ListOptions{
+			Where: map[string]string{"name": name, "env": env, "role": role},
+		})
+		if err != nil {
+			return fmt.Errorf("[ERROR] error looking up Ghost app: %v", err)
+		}
+
+		switch len(apps) {
+		case 0:
+			return fmt.Errorf("[ERROR] no Ghost app found with name %q, env %q and role %q", name, env, role)
+		case 1:
+			app = apps[0]
+		default:
+			return fmt.Errorf("[ERROR] %d Ghost apps found with name %q, env %q and role %q, expected one",
+				len(apps), name, env, role)
+		}
+	}
+
+	d.SetId(app.ID)
+
+	if err := flattenGhostApp(d, app); err != nil {
+		return fmt.Errorf("[ERROR] error reading Ghost app: %v", err)
+	}
+
+	return nil
+}
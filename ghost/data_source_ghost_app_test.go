@@ -0,0 +1,99 @@
+package ghost
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestAccDataSourceGhostAppBasic(t *testing.T) {
+	envName := fmt.Sprintf("data_ghost_app_acc_env_basic_%s", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckGhostAppDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceGhostAppConfig(envName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrPair("data.ghost_app.by_id", "name", "ghost_app.test", "name"),
+					resource.TestCheckResourceAttrPair("data.ghost_app.by_id", "vpc_id", "ghost_app.test", "vpc_id"),
+					resource.TestCheckResourceAttrPair("data.ghost_app.by_name", "id", "ghost_app.test", "id"),
+					resource.TestCheckResourceAttrPair("data.ghost_app.by_name",
+						"environment_infos.0.security_groups.#", "ghost_app.test", "environment_infos.0.security_groups.#"),
+					resource.TestCheckResourceAttrPair("data.ghost_app.by_name",
+						"build_infos.0.ami_name", "ghost_app.test", "build_infos.0.ami_name"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDataSourceGhostAppConfig(name string) string {
+	return testAccGhostAppConfig(name) + `
+      data "ghost_app" "by_id" {
+        id = "${ghost_app.test.id}"
+      }
+
+      data "ghost_app" "by_name" {
+        name = "${ghost_app.test.name}"
+        env  = "${ghost_app.test.env}"
+        role = "${ghost_app.test.role}"
+      }
+      `
+}
+
+func TestDataSourceGhostAppRead(t *testing.T) {
+	found := app
+	found.ID = "5accabf63d7eba00014e5679"
+
+	server := testGhostServer(nil,
+		testGhostRoute{Path: "/apps/" + found.ID, Body: found},
+		testGhostRoute{Path: "/apps", Handler: func(w http.ResponseWriter, r *http.Request) {
+			var items []ghost.App
+			switch {
+			case strings.Contains(r.URL.Query().Get("where"), `"env":"test"`):
+				items = []ghost.App{found}
+			case strings.Contains(r.URL.Query().Get("where"), `"env":"dup"`):
+				items = []ghost.App{found, found}
+			}
+			json.NewEncoder(w).Encode(ghost.Apps{Items: items})
+		}},
+	)
+	defer server.Close()
+
+	cases := []struct {
+		Config        map[string]interface{}
+		ExpectedError bool
+	}{
+		{map[string]interface{}{"id": found.ID}, false},
+		{map[string]interface{}{"name": "app_name", "env": "test", "role": "web"}, false},
+		{map[string]interface{}{"name": "app_name", "env": "none", "role": "web"}, true},
+		{map[string]interface{}{"name": "app_name", "env": "dup", "role": "web"}, true},
+		{map[string]interface{}{"name": "app_name"}, true},
+	}
+
+	for _, tc := range cases {
+		d := schema.TestResourceDataRaw(t, dataSourceGhostApp().Schema, tc.Config)
+		err := dataSourceGhostAppRead(d, testGhostMeta(server.URL))
+
+		if (err != nil) != tc.ExpectedError {
+			t.Fatalf("Unexpected error from dataSourceGhostAppRead with %v: %v", tc.Config, err)
+		}
+		if err != nil {
+			continue
+		}
+		if d.Id() != found.ID || d.Get("vpc_id").(string) != found.VpcID {
+			t.Fatalf("Unexpected app from dataSourceGhostAppRead with %v: %s %s",
+				tc.Config, d.Id(), d.Get("vpc_id"))
+		}
+	}
+}
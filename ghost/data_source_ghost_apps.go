@@ -0,0 +1,160 @@
+package ghost
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/terraform/helper/hashcode"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+func dataSourceGhostApps() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceGhostAppsRead,
+
+		Schema: map[string]*schema.Schema{
+			"env": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"role": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"region": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"vpc_id": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"name_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.ValidateRegexp,
+			},
+			"ids": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"apps": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"env": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"role": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"description": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"region": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"vpc_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"instance_type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceGhostAppsRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(lookupTimeout)
+	defer cancel()
+
+	where := map[string]string{}
+	for _, key := range []string{"env", "role", "region", "vpc_id"} {
+		if v, ok := d.GetOk(key); ok {
+			where[key] = v.(string)
+		}
+	}
+
+	log.Printf("[INFO] Listing Ghost apps matching %v", where)
+
+	apps, err := client.ListAppsWithContext(ctx, &ghost.ListOptions{Where: where})
+	if err != nil {
+		return fmt.Errorf("[ERROR] error listing Ghost apps: %v", err)
+	}
+
+	// Eve forbids $regex queries by default, names are filtered here
+	if v, ok := d.GetOk("name_regex"); ok {
+		re := regexp.MustCompile(v.(string))
+		filtered := []ghost.App{}
+		for _, app := range apps {
+			if re.MatchString(app.Name) {
+				filtered = append(filtered, app)
+			}
+		}
+		apps = filtered
+	}
+
+	sort.Slice(apps, func(i, j int) bool {
+		if apps[i].Name != apps[j].Name {
+			return apps[i].Name < apps[j].Name
+		}
+		return apps[i].ID < apps[j].ID
+	})
+
+	ids := []string{}
+	for _, app := range apps {
+		ids = append(ids, app.ID)
+	}
+
+	d.SetId(fmt.Sprintf("%d", hashcode.String(strings.Join(ids, ","))))
+	d.Set("ids", ids)
+	d.Set("apps", flattenGhostAppsSummary(apps))
+
+	return nil
+}
+
+func flattenGhostAppsSummary(apps []ghost.App) []interface{} {
+	appList := []interface{}{}
+
+	for _, app := range apps {
+		values := map[string]interface{}{
+			"id":            app.ID,
+			"name":          app.Name,
+			"env":           app.Env,
+			"role":          app.Role,
+			"description":   app.Description,
+			"region":        app.Region,
+			"vpc_id":        app.VpcID,
+			"instance_type": app.InstanceType,
+		}
+
+		appList = append(appList, values)
+	}
+
+	return appList
+}
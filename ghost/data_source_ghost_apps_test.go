@@ -0,0 +1,86 @@
+package ghost
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestAccDataSourceGhostAppsBasic(t *testing.T) {
+	envName := fmt.Sprintf("data_ghost_apps_acc_env_basic_%s", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckGhostAppDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceGhostAppsConfig(envName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.ghost_apps.test", "ids.#", "1"),
+					resource.TestCheckResourceAttrPair("data.ghost_apps.test", "ids.0", "ghost_app.test", "id"),
+					resource.TestCheckResourceAttrPair("data.ghost_apps.test", "apps.0.vpc_id", "ghost_app.test", "vpc_id"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDataSourceGhostAppsConfig(name string) string {
+	return testAccGhostAppConfig(name) + fmt.Sprintf(`
+      data "ghost_apps" "test" {
+        env        = "${ghost_app.test.env}"
+        role       = "${ghost_app.test.role}"
+        name_regex = "^%s$"
+      }
+      `, name)
+}
+
+func TestDataSourceGhostAppsRead(t *testing.T) {
+	// Two pages linked by _links.next, as Eve returns them
+	var page ghost.Apps
+	json.Unmarshal([]byte(`{"_links": {"next": {"href": "apps?page=2"}}}`), &page)
+	page.Items = []ghost.App{
+		{EveItemMetadata: ghost.EveItemMetadata{ID: "3"}, Name: "worker", Env: "prod"},
+		{EveItemMetadata: ghost.EveItemMetadata{ID: "2"}, Name: "front-b", Env: "prod"},
+	}
+
+	requests := []testGhostRequest{}
+	server := testGhostServer(&requests,
+		testGhostRoute{Times: 1, Body: page},
+		testGhostRoute{Body: ghost.Apps{Items: []ghost.App{
+			{EveItemMetadata: ghost.EveItemMetadata{ID: "1"}, Name: "front-a", Env: "prod", VpcID: "vpc-1"},
+		}}},
+	)
+	defer server.Close()
+
+	d := schema.TestResourceDataRaw(t, dataSourceGhostApps().Schema, map[string]interface{}{
+		"env":        "prod",
+		"name_regex": "^front",
+	})
+	if err := dataSourceGhostAppsRead(d, testGhostMeta(server.URL)); err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+
+	where := []string{}
+	for _, r := range requests {
+		where = append(where, r.Query.Get("where"))
+	}
+	if !reflect.DeepEqual(where, []string{`{"env":"prod"}`, ""}) {
+		t.Fatalf("Unexpected where filters.\nExpected: %#v\nGiven:    %#v", []string{`{"env":"prod"}`, ""}, where)
+	}
+
+	expectedIDs := []interface{}{"1", "2"}
+	if ids := d.Get("ids").([]interface{}); !reflect.DeepEqual(ids, expectedIDs) {
+		t.Fatalf("Unexpected ids.\nExpected: %#v\nGiven:    %#v", expectedIDs, ids)
+	}
+	if vpcID := d.Get("apps.0.vpc_id").(string); vpcID != "vpc-1" {
+		t.Fatalf("Unexpected apps.0.vpc_id: %s", vpcID)
+	}
+}
@@ -0,0 +1,185 @@
+package ghost
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/terraform/helper/hashcode"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+func dataSourceGhostDeployments() *schema.Resource {
+	deploymentSchema := map[string]*schema.Schema{
+		"id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"app_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"module": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"revision": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"commit": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"job_id": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"timestamp": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"user": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+	}
+
+	return &schema.Resource{
+		Read: dataSourceGhostDeploymentsRead,
+
+		Schema: map[string]*schema.Schema{
+			"app_id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: MatchesRegexp(`^[a-f0-9]{24}$`),
+			},
+			"module": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"revision": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"after": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.ValidateRFC3339TimeString,
+			},
+			"before": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.ValidateRFC3339TimeString,
+			},
+			"ids": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"deployments": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Resource{Schema: deploymentSchema},
+			},
+			"most_recent": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Resource{Schema: deploymentSchema},
+			},
+		},
+	}
+}
+
+func dataSourceGhostDeploymentsRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(lookupTimeout)
+	defer cancel()
+
+	where, err := expandGhostDeploymentsWhere(d)
+	if err != nil {
+		return fmt.Errorf("[ERROR] error listing Ghost deployments: %v", err)
+	}
+
+	log.Printf("[INFO] Listing Ghost deployments matching %v", where)
+
+	deployments, err := client.ListDeploymentsWithContext(ctx, &ghost.ListOptions{
+		Where: where,
+		Sort:  "-timestamp",
+	})
+	if err != nil {
+		return fmt.Errorf("[ERROR] error listing Ghost deployments: %v", err)
+	}
+
+	// Newest first, whatever the sort settings of the server
+	sort.SliceStable(deployments, func(i, j int) bool {
+		return deployments[i].Timestamp > deployments[j].Timestamp
+	})
+
+	ids := []string{}
+	for _, deployment := range deployments {
+		ids = append(ids, deployment.ID)
+	}
+
+	d.SetId(fmt.Sprintf("%d", hashcode.String(strings.Join(ids, ","))))
+	d.Set("ids", ids)
+	d.Set("deployments", flattenGhostDeployments(deployments))
+	if len(deployments) > 0 {
+		d.Set("most_recent", flattenGhostDeployments(deployments[:1]))
+	} else {
+		d.Set("most_recent", nil)
+	}
+
+	return nil
+}
+
+// Eve where filter of the data source arguments
+func expandGhostDeploymentsWhere(d *schema.ResourceData) (map[string]interface{}, error) {
+	where := map[string]interface{}{}
+	for _, key := range []string{"app_id", "module", "revision"} {
+		if v, ok := d.GetOk(key); ok {
+			where[key] = v.(string)
+		}
+	}
+
+	timestamp := map[string]interface{}{}
+	for key, operator := range map[string]string{"after": "$gte", "before": "$lte"} {
+		if v, ok := d.GetOk(key); ok {
+			t, err := time.Parse(time.RFC3339, v.(string))
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s date: %v", key, err)
+			}
+			timestamp[operator] = t.Unix()
+		}
+	}
+	if len(timestamp) > 0 {
+		where["timestamp"] = timestamp
+	}
+
+	return where, nil
+}
+
+func flattenGhostDeployments(deployments []ghost.Deployment) []interface{} {
+	deploymentList := []interface{}{}
+
+	for _, deployment := range deployments {
+		values := map[string]interface{}{
+			"id":        deployment.ID,
+			"app_id":    deployment.AppID,
+			"module":    deployment.Module,
+			"revision":  deployment.Revision,
+			"commit":    deployment.Commit,
+			"job_id":    deployment.JobID,
+			"timestamp": time.Unix(deployment.Timestamp, 0).UTC().Format(time.RFC3339),
+			"user":      deployment.User,
+		}
+
+		deploymentList = append(deploymentList, values)
+	}
+
+	return deploymentList
+}
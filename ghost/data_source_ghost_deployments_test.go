@@ -0,0 +1,94 @@
+package ghost
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestAccDataSourceGhostDeploymentsBasic(t *testing.T) {
+	envName := fmt.Sprintf("data_ghost_deployments_acc_env_basic_%s", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckGhostAppDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceGhostDeploymentsConfig(envName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.ghost_deployments.test", "deployments.#", "1"),
+					resource.TestCheckResourceAttr("data.ghost_deployments.test", "most_recent.0.module", "wordpress"),
+					resource.TestCheckResourceAttrPair("data.ghost_deployments.test", "most_recent.0.id",
+						"ghost_deployment.test", "modules.0.deployment_id"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDataSourceGhostDeploymentsConfig(name string) string {
+	return testAccGhostDeploymentConfig(name, "master") + `
+      data "ghost_deployments" "test" {
+        app_id = "${ghost_deployment.test.app_id}"
+        module = "wordpress"
+      }
+      `
+}
+
+func TestDataSourceGhostDeploymentsRead(t *testing.T) {
+	requests := []testGhostRequest{}
+	server := testGhostServer(&requests, testGhostRoute{Body: ghost.Deployments{Items: []ghost.Deployment{
+		{EveItemMetadata: ghost.EveItemMetadata{ID: "1"}, Module: "wordpress", Revision: "v1", Timestamp: 1527811200},
+		{EveItemMetadata: ghost.EveItemMetadata{ID: "2"}, Module: "wordpress", Revision: "v2", Commit: "9f1a2b3c",
+			JobID: "job_id", User: "demo", Timestamp: 1527897600},
+	}}})
+	defer server.Close()
+
+	d := schema.TestResourceDataRaw(t, dataSourceGhostDeployments().Schema, map[string]interface{}{
+		"app_id": "5accabf63d7eba00014e5679",
+		"module": "wordpress",
+		"after":  "2018-06-01T00:00:00Z",
+	})
+	if err := dataSourceGhostDeploymentsRead(d, testGhostMeta(server.URL)); err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+
+	expectedQuery := []string{
+		`{"app_id":"5accabf63d7eba00014e5679","module":"wordpress","timestamp":{"$gte":1527811200}}`,
+		"-timestamp",
+	}
+	query := []string{}
+	for _, r := range requests {
+		query = append(query, r.Query.Get("where"), r.Query.Get("sort"))
+	}
+	if !reflect.DeepEqual(query, expectedQuery) {
+		t.Fatalf("Unexpected query.\nExpected: %#v\nGiven:    %#v", expectedQuery, query)
+	}
+
+	expectedIDs := []interface{}{"2", "1"}
+	if ids := d.Get("ids").([]interface{}); !reflect.DeepEqual(ids, expectedIDs) {
+		t.Fatalf("Unexpected ids.\nExpected: %#v\nGiven:    %#v", expectedIDs, ids)
+	}
+
+	expectedMostRecent := []interface{}{
+		map[string]interface{}{
+			"id":        "2",
+			"app_id":    "",
+			"module":    "wordpress",
+			"revision":  "v2",
+			"commit":    "9f1a2b3c",
+			"job_id":    "job_id",
+			"timestamp": "2018-06-02T00:00:00Z",
+			"user":      "demo",
+		},
+	}
+	if mostRecent := d.Get("most_recent").([]interface{}); !reflect.DeepEqual(mostRecent, expectedMostRecent) {
+		t.Fatalf("Unexpected most_recent.\nExpected: %#v\nGiven:    %#v", expectedMostRecent, mostRecent)
+	}
+}
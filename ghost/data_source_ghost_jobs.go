@@ -0,0 +1,177 @@
+package ghost
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/terraform/helper/hashcode"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+var ghostJobStatuses = []string{
+	ghost.JobStatusInit,
+	ghost.JobStatusStarted,
+	ghost.JobStatusDone,
+	ghost.JobStatusFailed,
+	ghost.JobStatusAborted,
+	ghost.JobStatusCancelled,
+}
+
+func dataSourceGhostJobs() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceGhostJobsRead,
+
+		Schema: map[string]*schema.Schema{
+			"app_id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: MatchesRegexp(`^[a-f0-9]{24}$`),
+			},
+			"command": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(ghostJobCommands, false),
+			},
+			"status": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice(ghostJobStatuses, false),
+			},
+			"user": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"limit": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"ids": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"jobs": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"app_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"command": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"status": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"message": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"user": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"created": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"updated": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"options": {
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceGhostJobsRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(lookupTimeout)
+	defer cancel()
+
+	where := map[string]string{}
+	for _, key := range []string{"app_id", "command", "status", "user"} {
+		if v, ok := d.GetOk(key); ok {
+			where[key] = v.(string)
+		}
+	}
+
+	log.Printf("[INFO] Listing Ghost jobs matching %v", where)
+
+	// Newest first, so that limit keeps the last jobs
+	limit := d.Get("limit").(int)
+	opts := &ghost.ListOptions{Where: where, Sort: "-_created", MaxResults: limit}
+
+	jobs := []ghost.Job{}
+	err := client.ListJobsPagesWithContext(ctx, opts, func(page ghost.Jobs, lastPage bool) bool {
+		jobs = append(jobs, page.Items...)
+		return limit == 0 || len(jobs) < limit
+	})
+	if err != nil {
+		return fmt.Errorf("[ERROR] error listing Ghost jobs: %v", err)
+	}
+	if limit > 0 && len(jobs) > limit {
+		jobs = jobs[:limit]
+	}
+
+	ids := []string{}
+	for _, job := range jobs {
+		ids = append(ids, job.ID)
+	}
+
+	d.SetId(fmt.Sprintf("%d", hashcode.String(strings.Join(ids, ","))))
+	d.Set("ids", ids)
+	d.Set("jobs", flattenGhostJobsSummary(jobs))
+
+	return nil
+}
+
+func flattenGhostJobsSummary(jobs []ghost.Job) []interface{} {
+	jobList := []interface{}{}
+
+	for _, job := range jobs {
+		values := map[string]interface{}{
+			"id":      job.ID,
+			"app_id":  job.AppID,
+			"command": job.Command,
+			"status":  job.Status,
+			"message": job.Message,
+			"user":    job.User,
+			"created": "",
+			"updated": "",
+			"options": []interface{}{},
+		}
+		if job.Created != nil {
+			values["created"] = *job.Created
+		}
+		if job.Updated != nil {
+			values["updated"] = *job.Updated
+		}
+		if job.Options != nil {
+			values["options"] = flattenGhostAppStringList(*job.Options)
+		}
+
+		jobList = append(jobList, values)
+	}
+
+	return jobList
+}
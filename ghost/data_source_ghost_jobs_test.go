@@ -0,0 +1,123 @@
+package ghost
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestAccDataSourceGhostJobsBasic(t *testing.T) {
+	envName := fmt.Sprintf("data_ghost_jobs_acc_env_basic_%s", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckGhostAppDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDataSourceGhostJobsConfig(envName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("data.ghost_jobs.test", "jobs.#", "1"),
+					resource.TestCheckResourceAttrPair("data.ghost_jobs.test", "ids.0", "ghost_job.test", "id"),
+					resource.TestCheckResourceAttr("data.ghost_jobs.test", "jobs.0.status", "done"),
+				),
+			},
+		},
+	})
+}
+
+func testAccDataSourceGhostJobsConfig(name string) string {
+	return testAccGhostJobConfig(name) + `
+      data "ghost_jobs" "test" {
+        app_id  = "${ghost_job.test.app_id}"
+        command = "updatelifecyclehooks"
+        limit   = 1
+      }
+      `
+}
+
+func TestDataSourceGhostJobsRead(t *testing.T) {
+	created := "Fri, 01 Jun 2018 10:00:00 GMT"
+
+	cases := []struct {
+		Limit            int
+		ExpectedRequests int
+		ExpectedIDs      []interface{}
+	}{
+		{0, 2, []interface{}{"4", "3", "2", "1"}},
+		{3, 2, []interface{}{"4", "3", "2"}},
+		{2, 1, []interface{}{"4", "3"}},
+	}
+
+	for _, tc := range cases {
+		var page ghost.Jobs
+		json.Unmarshal([]byte(`{"_links": {"next": {"href": "jobs?page=2"}}}`), &page)
+		page.Items = []ghost.Job{
+			{EveItemMetadata: ghost.EveItemMetadata{ID: "4", Created: &created}, Command: "buildimage", Status: "failed",
+				Options: &[]string{"true"}},
+			{EveItemMetadata: ghost.EveItemMetadata{ID: "3"}, Command: "buildimage", Status: "done"},
+		}
+
+		requests := []testGhostRequest{}
+		server := testGhostServer(&requests,
+			testGhostRoute{Times: 1, Body: page},
+			testGhostRoute{Body: ghost.Jobs{Items: []ghost.Job{
+				{EveItemMetadata: ghost.EveItemMetadata{ID: "2"}, Command: "buildimage", Status: "done"},
+				{EveItemMetadata: ghost.EveItemMetadata{ID: "1"}, Command: "buildimage", Status: "done"},
+			}}},
+		)
+
+		raw := map[string]interface{}{
+			"app_id":  "5accabf63d7eba00014e5679",
+			"command": "buildimage",
+		}
+		if tc.Limit > 0 {
+			raw["limit"] = tc.Limit
+		}
+		d := schema.TestResourceDataRaw(t, dataSourceGhostJobs().Schema, raw)
+		err := dataSourceGhostJobsRead(d, testGhostMeta(server.URL))
+		server.Close()
+
+		if err != nil {
+			t.Fatalf("expected no error, but got %s", err)
+		}
+		if len(requests) != tc.ExpectedRequests {
+			t.Fatalf("Unexpected requests with limit %d.\nExpected: %d\nGiven:    %#v", tc.Limit, tc.ExpectedRequests, requests)
+		}
+		if ids := d.Get("ids").([]interface{}); !reflect.DeepEqual(ids, tc.ExpectedIDs) {
+			t.Fatalf("Unexpected ids with limit %d.\nExpected: %#v\nGiven:    %#v", tc.Limit, tc.ExpectedIDs, ids)
+		}
+		if d.Get("jobs.0.created").(string) != created || d.Get("jobs.0.options.0").(string) != "true" {
+			t.Fatalf("Unexpected jobs.0: %#v", d.Get("jobs.0"))
+		}
+	}
+}
+
+func TestDataSourceGhostJobsQuery(t *testing.T) {
+	requests := []testGhostRequest{}
+	server := testGhostServer(&requests, testGhostRoute{Body: ghost.Jobs{}})
+	defer server.Close()
+
+	d := schema.TestResourceDataRaw(t, dataSourceGhostJobs().Schema, map[string]interface{}{
+		"status": "failed",
+		"user":   "demo",
+		"limit":  5,
+	})
+	if err := dataSourceGhostJobsRead(d, testGhostMeta(server.URL)); err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+
+	expected := "max_results=5&sort=-_created&where=%7B%22status%22%3A%22failed%22%2C%22user%22%3A%22demo%22%7D"
+	if query := requests[0].Query.Encode(); query != expected {
+		t.Fatalf("Unexpected query.\nExpected: %s\nGiven:    %s", expected, query)
+	}
+	if ids := d.Get("ids").([]interface{}); len(ids) != 0 {
+		t.Fatalf("Unexpected ids: %#v", ids)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/base64"
 	"fmt"
 	"regexp"
+
+	"github.com/hashicorp/terraform/helper/schema"
 )
 
 func StrToB64(data string) string {
@@ -25,3 +27,26 @@ func MatchesRegexp(exp string) func(v interface{}, k string) (ws []string, error
 		return
 	}
 }
+
+// Build a data source schema exposing every attribute of a resource schema as computed
+func dataSourceSchemaFromResourceSchema(rs map[string]*schema.Schema) map[string]*schema.Schema {
+	ds := make(map[string]*schema.Schema, len(rs))
+
+	for k, v := range rs {
+		dv := &schema.Schema{
+			Type:     v.Type,
+			Computed: true,
+		}
+
+		switch elem := v.Elem.(type) {
+		case *schema.Resource:
+			dv.Elem = &schema.Resource{Schema: dataSourceSchemaFromResourceSchema(elem.Schema)}
+		case *schema.Schema:
+			dv.Elem = &schema.Schema{Type: elem.Type}
+		}
+
+		ds[k] = dv
+	}
+
+	return ds
+}
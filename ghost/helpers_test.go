@@ -3,6 +3,8 @@ package ghost
 import (
 	"reflect"
 	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
 )
 
 func TestStrToB64(t *testing.T) {
@@ -60,3 +62,20 @@ func TestMatchesRegexp(t *testing.T) {
 		}
 	}
 }
+
+func TestDataSourceSchemaFromResourceSchema(t *testing.T) {
+	dataSchema := dataSourceSchemaFromResourceSchema(resourceGhostApp().Schema)
+
+	cases := []*schema.Schema{
+		dataSchema["name"],
+		dataSchema["build_infos"],
+		dataSchema["build_infos"].Elem.(*schema.Resource).Schema["source_ami"],
+		dataSchema["environment_infos"].Elem.(*schema.Resource).Schema["security_groups"],
+	}
+
+	for _, s := range cases {
+		if !s.Computed || s.Optional || s.Required || s.Default != nil || s.ValidateFunc != nil {
+			t.Fatalf("Unexpected data source schema: %#v", s)
+		}
+	}
+}
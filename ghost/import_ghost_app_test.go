@@ -1,11 +1,17 @@
 package ghost
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
 	"testing"
 
+	"cloud-deploy.io/cloud-deploy-sdk-go"
 	"github.com/hashicorp/terraform/helper/acctest"
 	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
 )
 
 func TestAccGhostAppImportBasic(t *testing.T) {
@@ -25,6 +31,85 @@ func TestAccGhostAppImportBasic(t *testing.T) {
 				ImportState:       true,
 				ImportStateVerify: true,
 			},
+
+			resource.TestStep{
+				ResourceName:      "ghost_app.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateIdFunc: testAccGhostAppImportStateID("%s/%s/%s"),
+			},
+
+			resource.TestStep{
+				ResourceName:      "ghost_app.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateIdFunc: testAccGhostAppImportStateID("%s:%s:%s"),
+			},
 		},
 	})
 }
+
+// Build an import ID from the name, env and role of ghost_app.test
+func testAccGhostAppImportStateID(format string) resource.ImportStateIdFunc {
+	return func(s *terraform.State) (string, error) {
+		rs, ok := s.RootModule().Resources["ghost_app.test"]
+		if !ok {
+			return "", fmt.Errorf("Not found: ghost_app.test")
+		}
+
+		attrs := rs.Primary.Attributes
+		return fmt.Sprintf(format, attrs["name"], attrs["env"], attrs["role"]), nil
+	}
+}
+
+func TestResourceGhostAppImportState(t *testing.T) {
+	server := testGhostServer(nil, testGhostRoute{Path: "/apps", Handler: func(w http.ResponseWriter, r *http.Request) {
+		var items []ghost.App
+		where := r.URL.Query().Get("where")
+		switch {
+		case strings.Contains(where, `"name":"single"`):
+			items = []ghost.App{{EveItemMetadata: ghost.EveItemMetadata{ID: "1"}}}
+		case strings.Contains(where, `"name":"dup"`), strings.Contains(where, `"prod"`):
+			items = []ghost.App{
+				{EveItemMetadata: ghost.EveItemMetadata{ID: "1"}},
+				{EveItemMetadata: ghost.EveItemMetadata{ID: "2"}},
+			}
+		}
+		json.NewEncoder(w).Encode(ghost.Apps{Items: items})
+	}})
+	defer server.Close()
+
+	cases := []struct {
+		ImportID      string
+		ExpectedIDs   []string
+		ExpectedError bool
+	}{
+		{"5accabf63d7eba00014e5679", []string{"5accabf63d7eba00014e5679"}, false},
+		{"single/dev/webfront", []string{"1"}, false},
+		{"single:dev:webfront", []string{"1"}, false},
+		{"none/dev/webfront", nil, true},
+		{"dup/dev/webfront", nil, true},
+		{`{"env": "prod"}`, []string{"1", "2"}, false},
+		{"invalid", nil, true},
+	}
+
+	for _, tc := range cases {
+		d := resourceGhostApp().Data(nil)
+		d.SetId(tc.ImportID)
+		d.SetType("ghost_app")
+
+		results, err := resourceGhostAppImportState(d, testGhostMeta(server.URL))
+		if (err != nil) != tc.ExpectedError {
+			t.Fatalf("Unexpected error from resourceGhostAppImportState with %s: %v", tc.ImportID, err)
+		}
+
+		var ids []string
+		for _, result := range results {
+			ids = append(ids, result.Id())
+		}
+		if !reflect.DeepEqual(ids, tc.ExpectedIDs) {
+			t.Fatalf("Unexpected imported IDs with %s.\nExpected: %#v\nGiven:    %#v",
+				tc.ImportID, tc.ExpectedIDs, ids)
+		}
+	}
+}
@@ -1,15 +1,19 @@
 package ghost
 
 import (
+	"context"
 	"log"
+	"time"
 
+	"cloud-deploy.io/cloud-deploy-sdk-go"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 	"github.com/hashicorp/terraform/terraform"
 )
 
 // Provider represents a resource provider in Terraform
 func Provider() terraform.ResourceProvider {
-	return &schema.Provider{
+	provider := &schema.Provider{
 		Schema: map[string]*schema.Schema{
 			"user": {
 				Type:        schema.TypeString,
@@ -26,23 +30,138 @@ func Provider() terraform.ResourceProvider {
 				Required:    true,
 				DefaultFunc: schema.EnvDefaultFunc("GHOST_ENDPOINT", nil),
 			},
+			"request_timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("GHOST_REQUEST_TIMEOUT", 10),
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"ca_file": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc("GHOST_CA_FILE", ""),
+				ConflictsWith: []string{"ca_pem"},
+			},
+			"ca_pem": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"ca_file"},
+			},
+			"insecure": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("GHOST_INSECURE", false),
+			},
+			"client_cert": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("GHOST_CLIENT_CERT", ""),
+			},
+			"client_key": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("GHOST_CLIENT_KEY", ""),
+			},
+			"proxy_url": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("GHOST_PROXY_URL", ""),
+			},
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("GHOST_MAX_RETRIES", 3),
+				ValidateFunc: validation.IntAtLeast(0),
+			},
+			"retry_max_wait": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("GHOST_RETRY_MAX_WAIT", 30),
+				ValidateFunc: validation.IntAtLeast(1),
+			},
+			"full_document_updates": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("GHOST_FULL_DOCUMENT_UPDATES", false),
+			},
 		},
 
-		ResourcesMap: map[string]*schema.Resource{
-			"ghost_app": resourceGhostApp(),
+		DataSourcesMap: map[string]*schema.Resource{
+			"ghost_app":         dataSourceGhostApp(),
+			"ghost_apps":        dataSourceGhostApps(),
+			"ghost_deployments": dataSourceGhostDeployments(),
+			"ghost_jobs":        dataSourceGhostJobs(),
 		},
 
-		ConfigureFunc: providerConfigure,
+		ResourcesMap: map[string]*schema.Resource{
+			"ghost_app":             resourceGhostApp(),
+			"ghost_blue_green_pair": resourceGhostBlueGreenPair(),
+			"ghost_blue_green_swap": resourceGhostBlueGreenSwap(),
+			"ghost_deployment":      resourceGhostDeployment(),
+			"ghost_image":           resourceGhostImage(),
+			"ghost_job":             resourceGhostJob(),
+			"ghost_webhook":         resourceGhostWebhook(),
+		},
 	}
+
+	provider.ConfigureFunc = providerConfigure(provider)
+
+	return provider
+}
+
+// providerMeta is the meta data given to resources
+type providerMeta struct {
+	client *ghost.Client
+
+	// Cancelled when Terraform stops the provider, e.g. on Ctrl-C
+	stopContext context.Context
+
+	// Send the whole app on updates instead of its changed fields only
+	fullDocumentUpdates bool
 }
 
-func providerConfigure(data *schema.ResourceData) (interface{}, error) {
-	config := Config{
-		User:     data.Get("user").(string),
-		Password: data.Get("password").(string),
-		URL:      data.Get("endpoint").(string),
+// Timeout of data source reads and imports, which are not given resource timeouts
+const lookupTimeout = 5 * time.Minute
+
+// Returns a context for API calls bounded by timeout and cancelled when the
+// provider is stopped
+func (m *providerMeta) timeoutContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx := m.stopContext
+	if ctx == nil {
+		ctx = context.Background()
 	}
-	log.Println("[INFO] Initializing Ghost client")
+	return context.WithTimeout(ctx, timeout)
+}
+
+func providerConfigure(provider *schema.Provider) schema.ConfigureFunc {
+	return func(data *schema.ResourceData) (interface{}, error) {
+		config := Config{
+			User:     data.Get("user").(string),
+			Password: data.Get("password").(string),
+			URL:      data.Get("endpoint").(string),
+
+			RequestTimeout: time.Duration(data.Get("request_timeout").(int)) * time.Second,
+			CAFile:         data.Get("ca_file").(string),
+			CAPEM:          data.Get("ca_pem").(string),
+			Insecure:       data.Get("insecure").(bool),
+			ClientCert:     data.Get("client_cert").(string),
+			ClientKey:      data.Get("client_key").(string),
+			ProxyURL:       data.Get("proxy_url").(string),
 
-	return config.Client()
+			MaxRetries:   data.Get("max_retries").(int),
+			RetryMaxWait: time.Duration(data.Get("retry_max_wait").(int)) * time.Second,
+		}
+		log.Println("[INFO] Initializing Ghost client")
+
+		client, err := config.Client()
+		if err != nil {
+			return nil, err
+		}
+
+		return &providerMeta{
+			client:              client,
+			stopContext:         provider.StopContext(),
+			fullDocumentUpdates: data.Get("full_document_updates").(bool),
+		}, nil
+	}
 }
@@ -1,8 +1,20 @@
 package ghost
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"cloud-deploy.io/cloud-deploy-sdk-go"
 
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
@@ -41,3 +53,138 @@ func testAccPreCheck(t *testing.T) {
 		t.Fatal("GHOST_ENDPOINT must be set for acceptance tests")
 	}
 }
+
+// Provider meta for unit tests against a mock API server, retrying without delay
+func testGhostMeta(url string) *providerMeta {
+	client := ghost.NewClient(url, "user", "password")
+	client.RetryMinWait = time.Millisecond
+	client.RetryMaxWait = time.Millisecond
+	return &providerMeta{client: client, stopContext: context.Background()}
+}
+
+// Request received by a mock API server
+type testGhostRequest struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// Decode the JSON body of the request into v
+func (r testGhostRequest) Decode(v interface{}) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// Response of a mock API server to the requests with Method and Path, when set.
+// A Path ending with a slash matches every path under it, and a route with Times
+// set only answers that many requests. Body is written as is if it is a string
+// and encoded to JSON otherwise, unless Handler is set to answer instead.
+type testGhostRoute struct {
+	Method  string
+	Path    string
+	Times   int
+	Status  int
+	Body    interface{}
+	Handler http.HandlerFunc
+}
+
+func (route testGhostRoute) matches(r *http.Request) bool {
+	return (route.Method == "" || route.Method == r.Method) &&
+		(route.Path == "" || route.Path == r.URL.Path ||
+			strings.HasSuffix(route.Path, "/") && strings.HasPrefix(r.URL.Path, route.Path))
+}
+
+// Mock API server answering each request with the first matching route, or
+// with 404. The requests are recorded in requests, when not nil.
+func testGhostServer(requests *[]testGhostRequest, routes ...testGhostRoute) *httptest.Server {
+	var mutex sync.Mutex
+	answered := make([]int, len(routes))
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		mutex.Lock()
+		if requests != nil {
+			*requests = append(*requests, testGhostRequest{
+				Method: r.Method, Path: r.URL.Path, Query: r.URL.Query(), Header: r.Header, Body: body,
+			})
+		}
+		route := testGhostRoute{Status: 404}
+		for i, candidate := range routes {
+			if candidate.matches(r) && (candidate.Times == 0 || answered[i] < candidate.Times) {
+				answered[i]++
+				route = candidate
+				break
+			}
+		}
+		mutex.Unlock()
+
+		if route.Handler != nil {
+			route.Handler(w, r)
+			return
+		}
+		if route.Status != 0 {
+			w.WriteHeader(route.Status)
+		}
+		switch body := route.Body.(type) {
+		case nil:
+		case string:
+			w.Write([]byte(body))
+		default:
+			json.NewEncoder(w).Encode(body)
+		}
+	}))
+}
+
+// Routes of a job posted as job_id, read as job and logging log
+func testGhostJobRoutes(job ghost.Job, log string) []testGhostRoute {
+	return []testGhostRoute{
+		{Method: "POST", Path: "/jobs", Body: `{"_id": "job_id", "_status": "OK"}`},
+		{Path: "/jobs/job_id/logs", Body: log},
+		{Path: "/jobs/job_id", Body: job},
+	}
+}
+
+// Decoded JSON bodies of the requests sent with method
+func testGhostRequestBodies(requests []testGhostRequest, method string) []map[string]interface{} {
+	bodies := []map[string]interface{}{}
+	for _, r := range requests {
+		if r.Method == method {
+			body := map[string]interface{}{}
+			r.Decode(&body)
+			bodies = append(bodies, body)
+		}
+	}
+	return bodies
+}
+
+// Jobs posted in requests
+func testGhostPostedJobs(requests []testGhostRequest) []ghost.Job {
+	jobs := []ghost.Job{}
+	for _, r := range requests {
+		if r.Method == "POST" && r.Path == "/jobs" {
+			var job ghost.Job
+			r.Decode(&job)
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
+
+// Diff creating resource with the attributes set by config, as planned by Terraform
+func testGhostCreateDiff(resource *schema.Resource, config func(*schema.ResourceData)) *terraform.InstanceDiff {
+	d := resource.Data(nil)
+	config(d)
+	d.SetId("new")
+
+	diff := &terraform.InstanceDiff{Attributes: map[string]*terraform.ResourceAttrDiff{}}
+	for k, v := range d.State().Attributes {
+		if k != "id" {
+			diff.Attributes[k] = &terraform.ResourceAttrDiff{New: v}
+		}
+	}
+
+	return diff
+}
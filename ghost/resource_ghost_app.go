@@ -1,13 +1,20 @@
 package ghost
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/helper/validation"
 )
@@ -27,9 +34,11 @@ func resourceGhostApp() *schema.Resource {
 		},
 
 		Importer: &schema.ResourceImporter{
-			State: schema.ImportStatePassthrough,
+			State: resourceGhostAppImportState,
 		},
 
+		CustomizeDiff: resourceGhostAppCustomizeDiff,
+
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:         schema.TypeString,
@@ -113,9 +122,20 @@ func resourceGhostApp() *schema.Resource {
 							ValidateFunc: MatchesRegexp(`^[a-z\_][a-z0-9\_\-]{0,30}$`),
 						},
 						"source_ami": {
-							Type:         schema.TypeString,
-							Required:     true,
-							ValidateFunc: MatchesRegexp(`^ami-[a-z0-9]*$`),
+							Type:          schema.TypeString,
+							Optional:      true,
+							ConflictsWith: []string{"build_infos.0.source_container_image"},
+							ValidateFunc:  MatchesRegexp(`^ami-[a-z0-9]*$`),
+						},
+						"source_container_image": {
+							Type:          schema.TypeString,
+							Optional:      true,
+							ConflictsWith: []string{"build_infos.0.source_ami"},
+							ValidateFunc:  MatchesRegexp(`^[a-zA-Z0-9\.\-\_\/:@]*$`),
+						},
+						"container_image": {
+							Type:     schema.TypeString,
+							Computed: true,
 						},
 						"ami_name": {
 							Type:     schema.TypeString,
@@ -265,6 +285,7 @@ func resourceGhostApp() *schema.Resource {
 			"blue_green": {
 				Type:     schema.TypeList,
 				Optional: true,
+				MaxItems: 1,
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"enable_blue_green": {
@@ -279,6 +300,7 @@ func resourceGhostApp() *schema.Resource {
 						"is_online": {
 							Type:     schema.TypeBool,
 							Optional: true,
+							Computed: true,
 						},
 						"hooks": {
 							Type:     schema.TypeList,
@@ -300,6 +322,7 @@ func resourceGhostApp() *schema.Resource {
 						"alter_ego_id": {
 							Type:     schema.TypeString,
 							Optional: true,
+							Computed: true,
 						},
 					},
 				},
@@ -459,6 +482,70 @@ func resourceGhostApp() *schema.Resource {
 					},
 				},
 			},
+			"on_change": {
+				Type:     schema.TypeList,
+				Optional: true,
+				MaxItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"buildimage": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+						"updatelifecyclehooks": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+						"updateautoscaling": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+						"redeploy": {
+							Type:     schema.TypeBool,
+							Optional: true,
+							Default:  false,
+						},
+					},
+				},
+			},
+			"pending_changes": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"field": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"updated": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"user": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"required_commands": {
+				Type:     schema.TypeSet,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+			"required_actions": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"image_fingerprint": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
 			"etag": {
 				Type:     schema.TypeString,
 				Computed: true,
@@ -468,13 +555,21 @@ func resourceGhostApp() *schema.Resource {
 }
 
 func resourceGhostAppCreate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*ghost.Client)
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutCreate))
+	defer cancel()
 
 	log.Printf("[INFO] Creating Ghost app %s", d.Get("name").(string))
 	app := expandGhostApp(d)
+	if err := validateGhostAppBuildInfos(app.BuildInfos); err != nil {
+		return fmt.Errorf("[ERROR] error creating Ghost app: %v", err)
+	}
 
-	eveMetadata, err := client.CreateApp(app)
+	eveMetadata, err := client.CreateAppWithContext(ctx, app)
 	if err != nil {
+		if ghost.IsUnprocessableEntity(err) {
+			return fmt.Errorf("[ERROR] error creating Ghost app: %v", ghostAppIssuesError(err))
+		}
 		return fmt.Errorf("[ERROR] error creating Ghost app: %v", err)
 	}
 
@@ -485,16 +580,18 @@ func resourceGhostAppCreate(d *schema.ResourceData, meta interface{}) error {
 }
 
 func resourceGhostAppRead(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*ghost.Client)
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutRead))
+	defer cancel()
 
 	log.Printf("[INFO] Reading Ghost app %s", d.Get("name").(string))
 
-	app, err := client.GetApp(d.Id())
+	app, err := client.GetAppWithContext(ctx, d.Id())
 	if err != nil {
 		// If app was not found, return nil to show that app is gone
-		if err.Error()[len(err.Error())-3:] == "404" {
-			d.SetId("")
+		if ghost.IsNotFound(err) {
 			log.Printf("[WARN] Ghost app (%s) not found, removing from state", d.Id())
+			d.SetId("")
 			return nil
 		}
 		return fmt.Errorf("[ERROR] error reading Ghost app: %v", err)
@@ -504,40 +601,160 @@ func resourceGhostAppRead(d *schema.ResourceData, meta interface{}) error {
 		return fmt.Errorf("[ERROR] error reading Ghost app: %v", err)
 	}
 
+	// Actions are only shown in the plans changing the app
+	d.Set("required_actions", []interface{}{})
+
 	return nil
 }
 
 func resourceGhostAppUpdate(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*ghost.Client)
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutUpdate))
+	defer cancel()
 
 	log.Printf("[INFO] Updating Ghost app %s", d.Get("name").(string))
+	if err := validateGhostAppBuildInfos(expandGhostApp(d).BuildInfos); err != nil {
+		return fmt.Errorf("[ERROR] error updating Ghost app: %v", err)
+	}
 
-	app_updated := expandGhostApp(d)
+	// Commands to run once the app is updated, from the changes of the plan
+	commands := ghostAppOnChangeCommands(d)
+
+	// Only send the fields changed by the plan, so that the changes made by
+	// other tools survive, unless the provider is set to send the whole app
+	fullDocument := meta.(*providerMeta).fullDocumentUpdates
+	fields := ghostAppChangedFields(d)
+	update := func(etag string) (ghost.EveItemMetadata, error) {
+		app_updated := expandGhostApp(d)
+		if fullDocument {
+			return client.UpdateAppWithContext(ctx, &app_updated, d.Id(), etag)
+		}
+		values, err := expandGhostAppFields(app_updated, fields)
+		if err != nil {
+			return ghost.EveItemMetadata{}, err
+		}
+		return client.UpdateAppFieldsWithContext(ctx, values, d.Id(), etag)
+	}
 
-	eveMetadata, err := client.UpdateApp(&app_updated, d.Id(), d.Get("etag").(string))
-	if err != nil {
-		ec := err.Error()[len(err.Error())-3:]
-		if ec == "412" {
-			return fmt.Errorf(`[ERROR] error updating Ghost app: app has been updated since
-				last plan, you should run plan again: %v`, err)
+	// Nothing to send if only on_change changed
+	if fullDocument || len(fields) > 0 {
+		etag := d.Get("etag").(string)
+		eveMetadata, err := update(etag)
+
+		// The app has been updated since last plan: retry on top of the changes
+		// as long as they do not overlap the changes of the plan
+		for attempt := 1; ghost.IsPreconditionFailed(err) && attempt < ghostAppConflictRetries; attempt++ {
+			log.Printf("[WARN] Ghost app %s has been updated since last plan, rebasing the changes", d.Id())
+			if etag, err = rebaseGhostApp(ctx, client, d, false); err != nil {
+				return fmt.Errorf("[ERROR] error updating Ghost app: %v", err)
+			}
+
+			eveMetadata, err = update(etag)
 		}
-		return fmt.Errorf("[ERROR] error updating Ghost app: %v", err)
+		if err != nil {
+			if ghost.IsPreconditionFailed(err) {
+				return fmt.Errorf(`[ERROR] error updating Ghost app: app has been updated since
+					last plan, you should run plan again: %v`, err)
+			}
+			if ghost.IsUnprocessableEntity(err) {
+				return fmt.Errorf("[ERROR] error updating Ghost app: %v", ghostAppIssuesError(err))
+			}
+			return fmt.Errorf("[ERROR] error updating Ghost app: %v", err)
+		}
+
+		d.Set("etag", *eveMetadata.Etag)
 	}
 
-	d.Set("etag", *eveMetadata.Etag)
+	if err := resourceGhostAppRead(d, meta); err != nil {
+		return err
+	}
 
-	return resourceGhostAppRead(d, meta)
+	if len(commands) > 0 {
+		// Keep the previous values of the fields the commands were run for
+		// if one fails, so that the next apply runs them again
+		d.Partial(true)
+		triggers := ghostAppOnChangeFields(commands)
+		for key := range resourceGhostApp().Schema {
+			if !triggers[key] {
+				d.SetPartial(key)
+			}
+		}
+
+		// Jobs take much longer than API calls: they are bound by their own
+		// timeout rather than the update timeout
+		jobCtx, jobCancel := meta.(*providerMeta).timeoutContext(ghostAppOnChangeTimeout)
+		defer jobCancel()
+
+		for _, command := range commands {
+			if err := runGhostAppCommand(jobCtx, client, d, command); err != nil {
+				return fmt.Errorf("[ERROR] error running %s on Ghost app: %v", command, err)
+			}
+		}
+
+		d.Partial(false)
+
+		// The commands apply the pending changes
+		if err := resourceGhostAppRead(d, meta); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Show the Ghost commands the changes of the plan will need
+func resourceGhostAppCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if err := resourceGhostAppBuildInfosCustomizeDiff(d, meta); err != nil {
+		return err
+	}
+	if d.Id() == "" {
+		return nil
+	}
+
+	// Known once the new image sources are applied: a ghost_image built from
+	// the fingerprint is rebuilt after the app update
+	if ghostImageSourcesChanged(d) {
+		if err := d.SetNewComputed("image_fingerprint"); err != nil {
+			return err
+		}
+	}
+
+	actions := ghostAppRequiredActions(d)
+	if len(actions) == 0 {
+		return nil
+	}
+
+	log.Printf("[INFO] Changes of Ghost app %s need %s", d.Id(), strings.Join(actions, ", "))
+	return d.SetNew("required_actions", actions)
 }
 
 func resourceGhostAppDelete(d *schema.ResourceData, meta interface{}) error {
-	client := meta.(*ghost.Client)
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutDelete))
+	defer cancel()
 
 	log.Printf("[INFO] Deleting Ghost app %s", d.Get("name").(string))
 
-	err := client.DeleteApp(d.Id(), d.Get("etag").(string))
-	if err != nil {
-		ec := err.Error()[len(err.Error())-3:]
-		if ec == "412" {
+	etag := d.Get("etag").(string)
+	err := client.DeleteAppWithContext(ctx, d.Id(), etag)
+
+	// Delete the app anyway if only the server side state changed, such as
+	// the last deployment of modules
+	for attempt := 1; ghost.IsPreconditionFailed(err) && attempt < ghostAppConflictRetries; attempt++ {
+		log.Printf("[WARN] Ghost app %s has been updated since last destroy plan, checking the changes", d.Id())
+		if etag, err = rebaseGhostApp(ctx, client, d, true); err != nil {
+			if ghost.IsNotFound(err) {
+				break
+			}
+			return fmt.Errorf("[ERROR] error deleting Ghost app: %v", err)
+		}
+
+		err = client.DeleteAppWithContext(ctx, d.Id(), etag)
+	}
+
+	// The app is gone, such as when a retried request went through the first time
+	if err != nil && !ghost.IsNotFound(err) {
+		if ghost.IsPreconditionFailed(err) {
 			return fmt.Errorf(`[ERROR] error deleting Ghost app: app has been updated since
 					last destroy plan, you should run destroy plan again: %v`, err)
 		}
@@ -549,8 +766,294 @@ func resourceGhostAppDelete(d *schema.ResourceData, meta interface{}) error {
 	return nil
 }
 
+// Attempts at updating or deleting an app whose etag keeps changing
+const ghostAppConflictRetries = 3
+
+// Time left to the jobs run by on_change after an update
+var ghostAppOnChangeTimeout = 30 * time.Minute
+
+// Re-reads an app updated since the last plan and takes the changes made to
+// the attributes Terraform manages into d. Fails, naming the attributes, if
+// the plan changes them too, which a plan destroying the app always does.
+// Returns the new etag of the app.
+func rebaseGhostApp(ctx context.Context, client *ghost.Client, d *schema.ResourceData, destroying bool) (string, error) {
+	app, err := client.GetAppWithContext(ctx, d.Id())
+	if err != nil {
+		return "", err
+	}
+
+	current := resourceGhostApp().Data(nil)
+	flattenGhostApp(current, app)
+
+	conflicts := []string{}
+	rebased := map[string]interface{}{}
+	for key, attr := range resourceGhostApp().Schema {
+		if (!attr.Optional && !attr.Required) || key == "on_change" {
+			continue
+		}
+
+		before, after := d.GetChange(key)
+		value := ghostAppManagedValue(attr, current.Get(key))
+		if reflect.DeepEqual(value, ghostAppManagedValue(attr, before)) {
+			continue
+		}
+
+		if destroying || d.HasChange(key) {
+			if !reflect.DeepEqual(value, ghostAppManagedValue(attr, after)) {
+				conflicts = append(conflicts, key)
+			}
+			continue
+		}
+		rebased[key] = current.Get(key)
+	}
+
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return "", fmt.Errorf("app has been updated since last plan, you should run plan again: "+
+			"conflicting changes of %s", strings.Join(conflicts, ", "))
+	}
+
+	for key, value := range rebased {
+		log.Printf("[INFO] Keeping the changes of %s made to Ghost app %s since last plan", key, d.Id())
+		d.Set(key, value)
+	}
+
+	return *app.Etag, nil
+}
+
+// Strips the computed attributes of nested blocks, which the server updates
+// on its own, e.g. the last_deployment of modules
+func ghostAppManagedValue(attr *schema.Schema, value interface{}) interface{} {
+	resource, ok := attr.Elem.(*schema.Resource)
+	list, isList := value.([]interface{})
+	if !ok || !isList {
+		return value
+	}
+
+	managed := []interface{}{}
+	for _, elem := range list {
+		data, ok := elem.(map[string]interface{})
+		if !ok {
+			managed = append(managed, elem)
+			continue
+		}
+
+		values := map[string]interface{}{}
+		for k, v := range data {
+			if sub, ok := resource.Schema[k]; ok && (sub.Optional || sub.Required) {
+				values[k] = ghostAppManagedValue(sub, v)
+			}
+		}
+		managed = append(managed, values)
+	}
+
+	return managed
+}
+
+// Ghost app IDs are Mongo ObjectIds
+var ghostAppIDRegexp = regexp.MustCompile(`^[a-f0-9]{24}$`)
+
+// Import an app by ID, by "name/env/role" (or "name:env:role"), or import
+// every app matching an Eve where filter given as a JSON object
+func resourceGhostAppImportState(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(lookupTimeout)
+	defer cancel()
+
+	id := strings.TrimSpace(d.Id())
+	if ghostAppIDRegexp.MatchString(id) {
+		return []*schema.ResourceData{d}, nil
+	}
+
+	var where interface{}
+	switch {
+	case strings.HasPrefix(id, "{"):
+		where = id
+	case len(strings.Split(id, "/")) == 3:
+		parts := strings.Split(id, "/")
+		where = map[string]string{"name": parts[0], "env": parts[1], "role": parts[2]}
+	case len(strings.Split(id, ":")) == 3:
+		parts := strings.Split(id, ":")
+		where = map[string]string{"name": parts[0], "env": parts[1], "role": parts[2]}
+	default:
+		return nil, fmt.Errorf("[ERROR] invalid Ghost app import ID %q: expected an app ID, "+
+			"name/env/role, name:env:role or a JSON where filter", id)
+	}
+
+	log.Printf("[INFO] Looking up Ghost apps to import with %s", id)
+
+	apps, err := client.ListAppsWithContext(ctx, &ghost.ListOptions{Where: where})
+	if err != nil {
+		return nil, fmt.Errorf("[ERROR] error looking up Ghost app %s: %v", id, err)
+	}
+	if len(apps) == 0 {
+		return nil, fmt.Errorf("[ERROR] no Ghost app found matching %s", id)
+	}
+
+	// Only a where filter may import several apps at once
+	if len(apps) > 1 && !strings.HasPrefix(id, "{") {
+		ids := []string{}
+		for _, app := range apps {
+			ids = append(ids, app.ID)
+		}
+		return nil, fmt.Errorf("[ERROR] %d Ghost apps match %s, import one of them by ID: %s",
+			len(apps), id, strings.Join(ids, ", "))
+	}
+
+	results := []*schema.ResourceData{}
+	for i, app := range apps {
+		data := d
+		if i > 0 {
+			data = resourceGhostApp().Data(nil)
+			data.SetType("ghost_app")
+		}
+		data.SetId(app.ID)
+		results = append(results, data)
+	}
+
+	return results, nil
+}
+
+// Eve field names that differ from the Terraform attribute names
+var ghostAppAttributeNames = map[string]string{
+	"env_vars":        "environment_variables",
+	"safe-deployment": "safe_deployment",
+	"var_key":         "key",
+	"var_value":       "value",
+}
+
+// Turn the Eve validation issues of an API error into one error per
+// Terraform attribute
+func ghostAppIssuesError(err error) error {
+	return ghostIssuesError(err, ghostAppAttributePath)
+}
+
+// Turn the Eve validation issues of an API error into one error per
+// attribute, named by attributePath
+func ghostIssuesError(err error, attributePath func(string) string) error {
+	apiErr, ok := ghost.AsError(err)
+	if !ok || len(apiErr.Issues) == 0 {
+		return err
+	}
+
+	var result *multierror.Error
+	for _, field := range apiErr.IssueFields() {
+		result = multierror.Append(result, fmt.Errorf("%s: %s",
+			attributePath(field), apiErr.Issues[field]))
+	}
+
+	return result
+}
+
+// Convert a dotted Eve field path to the matching Terraform attribute path,
+// e.g. environment_infos.optional_volumes.1.iops becomes
+// environment_infos.0.optional_volumes.1.iops
+func ghostAppAttributePath(field string) string {
+	path := []string{}
+	elems := resourceGhostApp().Schema
+	segments := strings.Split(field, ".")
+
+	for i := 0; i < len(segments); i++ {
+		name := segments[i]
+		if tfName, ok := ghostAppAttributeNames[name]; ok {
+			name = tfName
+		}
+		path = append(path, name)
+
+		attr, ok := elems[name]
+		if !ok {
+			// Unknown to the schema, keep the remaining segments untouched
+			path = append(path, segments[i+1:]...)
+			break
+		}
+
+		resource, ok := attr.Elem.(*schema.Resource)
+		if attr.Type != schema.TypeList || !ok {
+			continue
+		}
+
+		// Nested blocks are lists in Terraform: keep the index Eve gives for
+		// arrays, use the first element for sub-documents
+		if i+1 < len(segments) {
+			if _, err := strconv.Atoi(segments[i+1]); err == nil {
+				path = append(path, segments[i+1])
+				i++
+			} else {
+				path = append(path, "0")
+			}
+		}
+		elems = resource.Schema
+	}
+
+	return strings.Join(path, ".")
+}
+
 // Get app from TF configuration
 func expandGhostApp(d *schema.ResourceData) ghost.App {
+	app := expandGhostAppSettings(d)
+	app.BlueGreen = expandGhostAppBlueGreen(d.Get("blue_green").([]interface{}))
+
+	return app
+}
+
+// API field names of the top-level Terraform attributes named differently,
+// from ghostAppAttributeNames
+var ghostAppFieldNames = map[string]string{}
+
+func init() {
+	attributes := resourceGhostApp().Schema
+	for name, tfName := range ghostAppAttributeNames {
+		if _, ok := attributes[tfName]; ok {
+			ghostAppFieldNames[tfName] = name
+		}
+	}
+}
+
+// Returns the API fields of the attributes changed by the plan
+func ghostAppChangedFields(d *schema.ResourceData) []string {
+	fields := []string{}
+	for key, attr := range resourceGhostApp().Schema {
+		if (!attr.Optional && !attr.Required) || key == "on_change" || !d.HasChange(key) {
+			continue
+		}
+
+		field := key
+		if name, ok := ghostAppFieldNames[key]; ok {
+			field = name
+		}
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+// Get the given API fields of app, as sent to the API. The fields left out
+// of the whole document, like a removed blue_green block, are sent as null
+// so that the API clears them.
+func expandGhostAppFields(app ghost.App, fields []string) (map[string]interface{}, error) {
+	data, err := json.Marshal(app)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	document := map[string]interface{}{}
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+	for _, field := range fields {
+		values[field] = document[field]
+	}
+
+	return values, nil
+}
+
+// Get the app settings shared with ghost_blue_green_pair from TF configuration
+func expandGhostAppSettings(d *schema.ResourceData) ghost.App {
 	app := ghost.App{
 		Name:               d.Get("name").(string),
 		Env:                d.Get("env").(string),
@@ -576,6 +1079,17 @@ func expandGhostApp(d *schema.ResourceData) ghost.App {
 }
 
 func flattenGhostApp(d *schema.ResourceData, app ghost.App) error {
+	d.Set("etag", app.Etag)
+	d.Set("blue_green", flattenGhostAppBlueGreen(app.BlueGreen))
+	d.Set("pending_changes", flattenGhostAppPendingChanges(app.PendingChanges))
+	d.Set("required_commands", schema.NewSet(schema.HashString, ghostAppRequiredCommands(app.PendingChanges)))
+	d.Set("image_fingerprint", ghostImageFingerprint(app))
+
+	return flattenGhostAppSettings(d, app)
+}
+
+// Set the app settings shared with ghost_blue_green_pair to TF state
+func flattenGhostAppSettings(d *schema.ResourceData, app ghost.App) error {
 	d.Set("name", app.Name)
 	d.Set("env", app.Env)
 	d.Set("role", app.Role)
@@ -584,7 +1098,6 @@ func flattenGhostApp(d *schema.ResourceData, app ghost.App) error {
 	d.Set("instance_type", app.InstanceType)
 	d.Set("vpc_id", app.VpcID)
 	d.Set("instance_monitoring", app.InstanceMonitoring)
-	d.Set("etag", app.Etag)
 
 	d.Set("modules", flattenGhostAppModules(app.Modules))
 	d.Set("build_infos", flattenGhostAppBuildInfos(app.BuildInfos))
@@ -599,6 +1112,209 @@ func flattenGhostApp(d *schema.ResourceData, app ghost.App) error {
 	return nil
 }
 
+func flattenGhostAppPendingChanges(pendingChanges *[]ghost.PendingChange) []interface{} {
+	values := []interface{}{}
+
+	if pendingChanges == nil {
+		return values
+	}
+
+	for _, change := range *pendingChanges {
+		values = append(values, map[string]interface{}{
+			"field":   change.Field,
+			"updated": change.Updated,
+			"user":    change.User,
+		})
+	}
+
+	return values
+}
+
+// Ghost commands to run for the changes of an app field to take effect.
+// Dotted fields take precedence over the section they belong to. It maps the
+// pending changes reported by Cloud Deploy as well as the changes of a plan.
+var ghostAppPendingChangeCommands = map[string][]string{
+	"build_infos":                     {"buildimage"},
+	"features":                        {"buildimage"},
+	"lifecycle_hooks":                 {"updatelifecyclehooks"},
+	"lifecycle_hooks.pre_buildimage":  {"buildimage"},
+	"lifecycle_hooks.post_buildimage": {"buildimage"},
+	"modules":                         {"redeploy"},
+	"env_vars":                        {"redeploy"},
+	"instance_type":                   {"updateautoscaling"},
+	"environment_infos":               {"updateautoscaling"},
+	"autoscale":                       {"updateautoscaling"},
+}
+
+// Order in which on_change runs the commands
+var ghostAppCommandsOrder = []string{"buildimage", "updatelifecyclehooks", "updateautoscaling", "redeploy"}
+
+// Returns the commands the changes of a field need, from the field itself or
+// else the section it belongs to
+func ghostAppFieldCommands(field string) []string {
+	for {
+		if commands, ok := ghostAppPendingChangeCommands[field]; ok {
+			return commands
+		}
+		i := strings.LastIndex(field, ".")
+		if i < 0 {
+			return nil
+		}
+		field = field[:i]
+	}
+}
+
+// Returns the sorted commands required by the pending changes of an app
+func ghostAppRequiredCommands(pendingChanges *[]ghost.PendingChange) []interface{} {
+	if pendingChanges == nil {
+		return []interface{}{}
+	}
+
+	required := map[string]bool{}
+	for _, change := range *pendingChanges {
+		commands := ghostAppFieldCommands(change.Field)
+		if commands == nil {
+			log.Printf("[DEBUG] No Ghost command known for pending change of %s", change.Field)
+		}
+		for _, command := range commands {
+			required[command] = true
+		}
+	}
+
+	commands := []string{}
+	for command := range required {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+
+	values := []interface{}{}
+	for _, command := range commands {
+		values = append(values, command)
+	}
+
+	return values
+}
+
+// Returns the fields a plan is checked on for the commands it needs: those of
+// ghostAppPendingChangeCommands, with the sections holding dotted fields split
+// into their attributes, so that each one gets the commands of its own field
+func ghostAppCommandFields() []string {
+	elems := resourceGhostApp().Schema
+
+	fields := []string{}
+	for field := range ghostAppPendingChangeCommands {
+		section := strings.Split(field, ".")[0]
+		if field != section {
+			if _, ok := ghostAppPendingChangeCommands[section]; !ok {
+				fields = append(fields, field)
+			}
+			continue
+		}
+
+		split := false
+		for other := range ghostAppPendingChangeCommands {
+			split = split || strings.HasPrefix(other, section+".")
+		}
+		resource, ok := elems[ghostAppAttributePath(section)].Elem.(*schema.Resource)
+		if !split || !ok {
+			fields = append(fields, field)
+			continue
+		}
+		for key := range resource.Schema {
+			fields = append(fields, section+"."+key)
+		}
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+// Returns the commands that the changes of a plan need, from its
+// ResourceData or ResourceDiff, in the order on_change runs them
+func ghostAppRequiredActions(d interface {
+	HasChange(string) bool
+}) []string {
+	required := map[string]bool{}
+	for _, field := range ghostAppCommandFields() {
+		if d.HasChange(ghostAppAttributePath(field)) {
+			for _, command := range ghostAppFieldCommands(field) {
+				required[command] = true
+			}
+		}
+	}
+
+	actions := []string{}
+	for _, command := range ghostAppCommandsOrder {
+		if required[command] {
+			actions = append(actions, command)
+		}
+	}
+
+	return actions
+}
+
+// Returns the commands enabled in on_change that the changes of the plan need
+func ghostAppOnChangeCommands(d *schema.ResourceData) []string {
+	commands := []string{}
+
+	onChange := d.Get("on_change").([]interface{})
+	if len(onChange) == 0 || onChange[0] == nil {
+		return commands
+	}
+	enabled := onChange[0].(map[string]interface{})
+
+	for _, command := range ghostAppRequiredActions(d) {
+		if enabled[command].(bool) {
+			commands = append(commands, command)
+		}
+	}
+
+	return commands
+}
+
+// Returns the top level attributes whose changes the commands are run for
+func ghostAppOnChangeFields(commands []string) map[string]bool {
+	run := map[string]bool{}
+	for _, command := range commands {
+		run[command] = true
+	}
+
+	fields := map[string]bool{}
+	for _, field := range ghostAppCommandFields() {
+		for _, command := range ghostAppFieldCommands(field) {
+			if run[command] {
+				fields[strings.Split(ghostAppAttributePath(field), ".")[0]] = true
+			}
+		}
+	}
+	return fields
+}
+
+// Runs a command on the app and waits for it. Modules are redeployed one by
+// one, at their last deployment.
+func runGhostAppCommand(ctx context.Context, client *ghost.Client, d *schema.ResourceData, command string) error {
+	jobs := []ghost.Job{}
+	if command == "redeploy" {
+		for _, config := range d.Get("modules").([]interface{}) {
+			data := config.(map[string]interface{})
+			if deployment := data["last_deployment"].(string); deployment != "" {
+				jobs = append(jobs, ghost.Job{Command: command, AppID: d.Id(), Options: &[]string{deployment}})
+			}
+		}
+	} else {
+		jobs = append(jobs, ghost.Job{Command: command, AppID: d.Id()})
+	}
+
+	for _, job := range jobs {
+		log.Printf("[INFO] Running %s on Ghost app %s", command, d.Id())
+		if _, err := runGhostJob(ctx, client, job, ""); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Get modules from TF configuration
 func expandGhostAppModules(d []interface{}) *[]ghost.Module {
 	modules := &[]ghost.Module{}
@@ -845,14 +1561,46 @@ func expandGhostAppBuildInfos(d []interface{}) *ghost.BuildInfos {
 	data := d[0].(map[string]interface{})
 
 	buildInfos := &ghost.BuildInfos{
-		SshUsername: data["ssh_username"].(string),
-		SourceAmi:   data["source_ami"].(string),
-		SubnetID:    data["subnet_id"].(string),
+		SshUsername:          data["ssh_username"].(string),
+		SourceAmi:            data["source_ami"].(string),
+		SourceContainerImage: data["source_container_image"].(string),
+		SubnetID:             data["subnet_id"].(string),
 	}
 
 	return buildInfos
 }
 
+// Checks build_infos at plan time. Also used by ghost_blue_green_pair.
+func resourceGhostAppBuildInfosCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	return validateGhostAppPlannedBuildInfos(d)
+}
+
+// A source given by another resource reads as empty until it is known, so
+// the plan only rejects both sources: a missing one is reported on apply
+func validateGhostAppPlannedBuildInfos(d interface {
+	Get(string) interface{}
+}) error {
+	// build_infos is required: a missing block is reported by the schema
+	buildInfos := d.Get("build_infos").([]interface{})
+	if len(buildInfos) == 0 || buildInfos[0] == nil {
+		return nil
+	}
+
+	planned := expandGhostAppBuildInfos(buildInfos)
+	if planned.SourceAmi != "" && planned.SourceContainerImage != "" {
+		return validateGhostAppBuildInfos(planned)
+	}
+	return nil
+}
+
+// Images are built either from an AMI or from a container image
+func validateGhostAppBuildInfos(buildInfos *ghost.BuildInfos) error {
+	if buildInfos == nil || (buildInfos.SourceAmi == "") == (buildInfos.SourceContainerImage == "") {
+		return fmt.Errorf("exactly one of build_infos.0.source_ami or build_infos.0.source_container_image must be set")
+	}
+	return nil
+}
+
 func flattenGhostAppBuildInfos(buildInfos *ghost.BuildInfos) []interface{} {
 	values := []interface{}{}
 
@@ -861,10 +1609,12 @@ func flattenGhostAppBuildInfos(buildInfos *ghost.BuildInfos) []interface{} {
 	}
 
 	values = append(values, map[string]interface{}{
-		"ssh_username": buildInfos.SshUsername,
-		"source_ami":   buildInfos.SourceAmi,
-		"ami_name":     buildInfos.AmiName,
-		"subnet_id":    buildInfos.SubnetID,
+		"ssh_username":           buildInfos.SshUsername,
+		"source_ami":             buildInfos.SourceAmi,
+		"ami_name":               buildInfos.AmiName,
+		"subnet_id":              buildInfos.SubnetID,
+		"source_container_image": buildInfos.SourceContainerImage,
+		"container_image":        buildInfos.ContainerImage,
 	})
 
 	return values
@@ -1100,6 +1850,77 @@ func flattenGhostAppSafeDeployment(safeDeployment *ghost.SafeDeployment) []inter
 	return values
 }
 
+// Get blue_green from TF configuration
+func expandGhostAppBlueGreen(d []interface{}) *ghost.BlueGreen {
+	if len(d) == 0 || d[0] == nil {
+		return nil
+	}
+
+	data := d[0].(map[string]interface{})
+
+	blueGreen := &ghost.BlueGreen{
+		EnableBlueGreen: data["enable_blue_green"].(bool),
+		Color:           data["color"].(string),
+		IsOnline:        data["is_online"].(bool),
+		AlterEgoID:      data["alter_ego_id"].(string),
+		Hooks:           expandGhostAppBlueGreenHooks(data["hooks"].([]interface{})),
+	}
+
+	return blueGreen
+}
+
+func flattenGhostAppBlueGreen(blueGreen *ghost.BlueGreen) []interface{} {
+	values := []interface{}{}
+
+	// Ghost returns an empty object when blue/green is not set up
+	if blueGreen == nil || (!blueGreen.EnableBlueGreen && !blueGreen.IsOnline &&
+		blueGreen.Color == "" && blueGreen.AlterEgoID == "" &&
+		flattenGhostAppBlueGreenHooks(blueGreen.Hooks) == nil) {
+		return values
+	}
+
+	values = append(values, map[string]interface{}{
+		"enable_blue_green": blueGreen.EnableBlueGreen,
+		"color":             blueGreen.Color,
+		"is_online":         blueGreen.IsOnline,
+		"alter_ego_id":      blueGreen.AlterEgoID,
+		"hooks":             flattenGhostAppBlueGreenHooks(blueGreen.Hooks),
+	})
+
+	return values
+}
+
+func expandGhostAppBlueGreenHooks(d []interface{}) *ghost.BlueGreenHooks {
+	if len(d) == 0 || d[0] == nil {
+		return nil
+	}
+
+	data := d[0].(map[string]interface{})
+
+	hooks := &ghost.BlueGreenHooks{
+		PreSwap:  StrToB64(data["pre_swap"].(string)),
+		PostSwap: StrToB64(data["post_swap"].(string)),
+	}
+
+	return hooks
+}
+
+func flattenGhostAppBlueGreenHooks(hooks *ghost.BlueGreenHooks) []interface{} {
+	values := []interface{}{}
+
+	// Ghost returns empty hooks when none are defined
+	if hooks == nil || (hooks.PreSwap == "" && hooks.PostSwap == "") {
+		return nil
+	}
+
+	values = append(values, map[string]interface{}{
+		"pre_swap":  B64ToStr(hooks.PreSwap),
+		"post_swap": B64ToStr(hooks.PostSwap),
+	})
+
+	return values
+}
+
 // Check that the struct is empty meaning that there's no change
 func hasNoChangeAutoscale(k string, d *schema.ResourceData) bool {
 	val, ok := d.GetOk("autoscale")
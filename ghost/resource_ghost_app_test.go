@@ -1,10 +1,17 @@
 package ghost
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
 	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"cloud-deploy.io/cloud-deploy-sdk-go"
 	"github.com/hashicorp/terraform/helper/acctest"
@@ -65,6 +72,21 @@ func TestAccGhostAppBasic(t *testing.T) {
 	})
 }
 
+func TestAccGhostAppBuildInfosNoImageSource(t *testing.T) {
+	envName := fmt.Sprintf("ghost_app_acc_env_no_source_%s", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:  func() { testAccPreCheck(t) },
+		Providers: testAccProviders,
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccGhostAppConfigNoImageSource(envName),
+				ExpectError: regexp.MustCompile("exactly one of build_infos.0.source_ami or build_infos.0.source_container_image must be set"),
+			},
+		},
+	})
+}
+
 func testAccCheckGhostAppExists(name string) resource.TestCheckFunc {
 	return func(s *terraform.State) error {
 		rs, ok := s.RootModule().Resources[name]
@@ -76,19 +98,27 @@ func testAccCheckGhostAppExists(name string) resource.TestCheckFunc {
 			return fmt.Errorf("No Ghost Application ID is set")
 		}
 
-		log.Printf("[INFO] Try to connect to Ghost and get all apps")
-		client := testAccProvider.Meta().(*ghost.Client)
-		_, err := client.GetApps()
+		log.Printf("[INFO] Try to connect to Ghost and list apps named %s", rs.Primary.Attributes["name"])
+		client := testAccProvider.Meta().(*providerMeta).client
+		apps, err := client.ListApps(&ghost.ListOptions{
+			Where: map[string]string{"name": rs.Primary.Attributes["name"]},
+		})
 		if err != nil {
 			return fmt.Errorf("Ghost environment not reachable: %v", err)
 		}
 
-		return nil
+		for _, app := range apps {
+			if app.ID == rs.Primary.ID {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("Ghost app %s not found in apps listing", rs.Primary.ID)
 	}
 }
 
 func testAccCheckGhostAppDestroy(s *terraform.State) error {
-	client := testAccProvider.Meta().(*ghost.Client)
+	client := testAccProvider.Meta().(*providerMeta).client
 
 	// Iterates through ghost apps
 	for _, rs := range s.RootModule().Resources {
@@ -102,7 +132,21 @@ func testAccCheckGhostAppDestroy(s *terraform.State) error {
 		// Try to get ghost app
 		_, err := client.GetApp(app_id)
 		if err == nil {
-			return fmt.Errorf("[INFO] Ghost app still exists: " + app_id)
+			return fmt.Errorf("[INFO] Ghost app still exists: %s", app_id)
+		}
+		if !ghost.IsNotFound(err) {
+			return fmt.Errorf("[ERROR] error checking Ghost app %s: %v", app_id, err)
+		}
+
+		// Look for leftovers with the same name through every page of apps
+		apps, err := client.ListApps(&ghost.ListOptions{
+			Where: map[string]string{"name": rs.Primary.Attributes["name"]},
+		})
+		if err != nil {
+			return fmt.Errorf("[ERROR] error listing Ghost apps: %v", err)
+		}
+		if len(apps) > 0 {
+			return fmt.Errorf("[INFO] Ghost app still exists: %s", apps[0].ID)
 		}
 	}
 
@@ -342,6 +386,12 @@ func testAccGhostAppConfigOmitEmpty(name string) string {
       `, name)
 }
 
+// Neither source_ami nor source_container_image: rejected on apply
+func testAccGhostAppConfigNoImageSource(name string) string {
+	return strings.Replace(testAccGhostAppConfigOmitEmpty(name),
+		`source_ami   = "ami-03ce4474"`, "", 1)
+}
+
 // Variables used for unit tests
 var (
 	app = ghost.App{
@@ -413,9 +463,25 @@ var (
 			WaitBeforeDeploy: 10,
 			WaitAfterDeploy:  10,
 		},
+		BlueGreen: &ghost.BlueGreen{
+			EnableBlueGreen: true,
+			Color:           "blue",
+			IsOnline:        true,
+			AlterEgoID:      "5accabf63d7eba00014e5679",
+			Hooks: &ghost.BlueGreenHooks{
+				PreSwap:  StrToB64("#!/usr/bin/env bash"),
+				PostSwap: StrToB64("#!/usr/bin/env bash"),
+			},
+		},
 	}
 )
 
+var containerBuildInfos = &ghost.BuildInfos{
+	SshUsername:          "admin",
+	SubnetID:             "subnet-1",
+	SourceContainerImage: "debian/9",
+}
+
 // Expanders Unit Tests
 func TestExpandGhostAppStringList(t *testing.T) {
 	cases := []struct {
@@ -592,14 +658,29 @@ func TestExpandGhostAppBuildInfos(t *testing.T) {
 		{
 			[]interface{}{
 				map[string]interface{}{
-					"ssh_username": "admin",
-					"source_ami":   "ami-1",
-					"subnet_id":    "subnet-1",
-					"ami_name":     "",
+					"ssh_username":           "admin",
+					"source_ami":             "ami-1",
+					"subnet_id":              "subnet-1",
+					"ami_name":               "",
+					"source_container_image": "",
+					"container_image":        "",
 				},
 			},
 			app.BuildInfos,
 		},
+		{
+			[]interface{}{
+				map[string]interface{}{
+					"ssh_username":           "admin",
+					"source_ami":             "",
+					"subnet_id":              "subnet-1",
+					"ami_name":               "",
+					"source_container_image": "debian/9",
+					"container_image":        "",
+				},
+			},
+			containerBuildInfos,
+		},
 	}
 
 	for _, tc := range cases {
@@ -611,6 +692,75 @@ func TestExpandGhostAppBuildInfos(t *testing.T) {
 	}
 }
 
+func TestGhostAppBuildInfosRoundTrip(t *testing.T) {
+	for _, buildInfos := range []*ghost.BuildInfos{app.BuildInfos, containerBuildInfos} {
+		output := expandGhostAppBuildInfos(flattenGhostAppBuildInfos(buildInfos))
+		if !reflect.DeepEqual(output, buildInfos) {
+			t.Fatalf("Unexpected output from round trip.\nExpected: %#v\nGiven:    %#v",
+				buildInfos, output)
+		}
+	}
+}
+
+func TestValidateGhostAppBuildInfos(t *testing.T) {
+	cases := []struct {
+		Input         *ghost.BuildInfos
+		ExpectedError bool
+	}{
+		{app.BuildInfos, false},
+		{containerBuildInfos, false},
+		{&ghost.BuildInfos{SubnetID: "subnet-1"}, true},
+		{&ghost.BuildInfos{SourceAmi: "ami-1", SourceContainerImage: "debian/9"}, true},
+		{nil, true},
+	}
+
+	for _, tc := range cases {
+		err := validateGhostAppBuildInfos(tc.Input)
+		if (err != nil) != tc.ExpectedError {
+			t.Fatalf("Unexpected output from validateGhostAppBuildInfos(%#v): %v", tc.Input, err)
+		}
+	}
+}
+
+// Planned attributes, as seen by ResourceDiff.Get
+type testGhostAppPlan map[string]interface{}
+
+func (p testGhostAppPlan) Get(key string) interface{} {
+	return p[key]
+}
+
+func TestValidateGhostAppPlannedBuildInfos(t *testing.T) {
+	cases := []struct {
+		SourceAmi            string
+		SourceContainerImage string
+		ExpectedError        bool
+	}{
+		{"ami-1", "", false},
+		{"", "debian/9", false},
+		// source_ami given by another resource, unknown until apply
+		{"", "", false},
+		{"ami-1", "debian/9", true},
+	}
+
+	for _, tc := range cases {
+		plan := testGhostAppPlan{
+			"build_infos": []interface{}{
+				map[string]interface{}{
+					"ssh_username":           "admin",
+					"source_ami":             tc.SourceAmi,
+					"source_container_image": tc.SourceContainerImage,
+					"ami_name":               "",
+					"subnet_id":              "subnet-1",
+				},
+			},
+		}
+		err := validateGhostAppPlannedBuildInfos(plan)
+		if (err != nil) != tc.ExpectedError {
+			t.Fatalf("Unexpected output from validateGhostAppPlannedBuildInfos(%#v): %v", plan, err)
+		}
+	}
+}
+
 func TestExpandGhostAppFeatures(t *testing.T) {
 	cases := []struct {
 		Input          []interface{}
@@ -886,6 +1036,55 @@ func TestExpandGhostSafeDeployment(t *testing.T) {
 	}
 }
 
+func TestExpandGhostAppBlueGreen(t *testing.T) {
+	cases := []struct {
+		Input          []interface{}
+		ExpectedOutput *ghost.BlueGreen
+	}{
+		{
+			[]interface{}{
+				map[string]interface{}{
+					"enable_blue_green": true,
+					"color":             "blue",
+					"is_online":         true,
+					"alter_ego_id":      "5accabf63d7eba00014e5679",
+					"hooks": []interface{}{
+						map[string]interface{}{
+							"pre_swap":  "#!/usr/bin/env bash",
+							"post_swap": "#!/usr/bin/env bash",
+						},
+					},
+				},
+			},
+			app.BlueGreen,
+		},
+		{
+			[]interface{}{
+				map[string]interface{}{
+					"enable_blue_green": false,
+					"color":             "",
+					"is_online":         false,
+					"alter_ego_id":      "",
+					"hooks":             []interface{}{},
+				},
+			},
+			&ghost.BlueGreen{},
+		},
+		{
+			nil,
+			nil,
+		},
+	}
+
+	for _, tc := range cases {
+		output := expandGhostAppBlueGreen(tc.Input)
+		if !reflect.DeepEqual(output, tc.ExpectedOutput) {
+			t.Fatalf("Unexpected output from expander.\nExpected: %#v\nGiven:    %#v",
+				tc.ExpectedOutput, output)
+		}
+	}
+}
+
 // Flatteners Unit Tests
 func TestFlattenGhostAppStringList(t *testing.T) {
 	cases := []struct {
@@ -1063,10 +1262,30 @@ func TestFlattenGhostAppBuildInfos(t *testing.T) {
 			app.BuildInfos,
 			[]interface{}{
 				map[string]interface{}{
-					"ssh_username": "admin",
-					"source_ami":   "ami-1",
-					"subnet_id":    "subnet-1",
-					"ami_name":     "",
+					"ssh_username":           "admin",
+					"source_ami":             "ami-1",
+					"subnet_id":              "subnet-1",
+					"ami_name":               "",
+					"source_container_image": "",
+					"container_image":        "",
+				},
+			},
+		},
+		{
+			&ghost.BuildInfos{
+				SshUsername:          "admin",
+				SubnetID:             "subnet-1",
+				SourceContainerImage: "debian/9",
+				ContainerImage:       "app_name-web-test-1",
+			},
+			[]interface{}{
+				map[string]interface{}{
+					"ssh_username":           "admin",
+					"source_ami":             "",
+					"subnet_id":              "subnet-1",
+					"ami_name":               "",
+					"source_container_image": "debian/9",
+					"container_image":        "app_name-web-test-1",
 				},
 			},
 		},
@@ -1303,6 +1522,48 @@ func TestFlattenGhostSafeDeployment(t *testing.T) {
 	}
 }
 
+func TestFlattenGhostAppBlueGreen(t *testing.T) {
+	cases := []struct {
+		Input          *ghost.BlueGreen
+		ExpectedOutput []interface{}
+	}{
+		{
+			app.BlueGreen,
+			[]interface{}{
+				map[string]interface{}{
+					"enable_blue_green": true,
+					"color":             "blue",
+					"is_online":         true,
+					"alter_ego_id":      "5accabf63d7eba00014e5679",
+					"hooks": []interface{}{
+						map[string]interface{}{
+							"pre_swap":  "#!/usr/bin/env bash",
+							"post_swap": "#!/usr/bin/env bash",
+						},
+					},
+				},
+			},
+		},
+		// Empty object sent back by Ghost
+		{
+			&ghost.BlueGreen{Hooks: &ghost.BlueGreenHooks{}},
+			[]interface{}{},
+		},
+		{
+			nil,
+			[]interface{}{},
+		},
+	}
+
+	for _, tc := range cases {
+		output := flattenGhostAppBlueGreen(tc.Input)
+		if !reflect.DeepEqual(output, tc.ExpectedOutput) {
+			t.Fatalf("Unexpected output from flattener.\nExpected: %#v\nGiven:    %#v",
+				tc.ExpectedOutput, output)
+		}
+	}
+}
+
 func TestSuppressDiffFeatures(t *testing.T) {
 	suppressFunc := suppressDiffFeaturesParameters()
 
@@ -1449,3 +1710,852 @@ func TestSuppressDiffSafeDeployment(t *testing.T) {
 		}
 	}
 }
+
+// CRUD Unit Tests
+func TestResourceGhostAppReadErrors(t *testing.T) {
+	cases := []struct {
+		StatusCode    int
+		Body          string
+		ExpectedID    string
+		ExpectedError bool
+	}{
+		{404, `{"_status": "ERR", "_error": {"code": 404, "message": "Not Found"}}`, "", false},
+		{500, `{"_status": "ERR", "_error": {"code": 500, "message": "Internal Error 404"}}`, "app_id", true},
+		{502, `Bad Gateway 404`, "app_id", true},
+	}
+
+	for _, tc := range cases {
+		server := testGhostServer(nil, testGhostRoute{Status: tc.StatusCode, Body: tc.Body})
+
+		state, err := resourceGhostApp().Refresh(&terraform.InstanceState{ID: "app_id"}, testGhostMeta(server.URL))
+		server.Close()
+
+		if (err != nil) != tc.ExpectedError {
+			t.Fatalf("Unexpected error from resourceGhostAppRead with HTTP %d: %v", tc.StatusCode, err)
+		}
+		id := ""
+		if state != nil {
+			id = state.ID
+		}
+		if id != tc.ExpectedID {
+			t.Fatalf("Unexpected ID after resourceGhostAppRead with HTTP %d.\nExpected: %#v\nGiven:    %#v",
+				tc.StatusCode, tc.ExpectedID, id)
+		}
+	}
+}
+
+func TestGhostAppAttributePath(t *testing.T) {
+	cases := []struct {
+		Input          string
+		ExpectedOutput string
+	}{
+		{"name", "name"},
+		{"environment_infos.optional_volumes.1.iops", "environment_infos.0.optional_volumes.1.iops"},
+		{"environment_infos.root_block_device.size", "environment_infos.0.root_block_device.0.size"},
+		{"env_vars.0.var_key", "environment_variables.0.key"},
+		{"safe-deployment.wait_before_deploy", "safe_deployment.0.wait_before_deploy"},
+		{"modules.2", "modules.2"},
+		{"unknown.field.0", "unknown.field.0"},
+	}
+
+	for _, tc := range cases {
+		output := ghostAppAttributePath(tc.Input)
+		if !reflect.DeepEqual(output, tc.ExpectedOutput) {
+			t.Fatalf("Unexpected output from ghostAppAttributePath.\nExpected: %#v\nGiven:    %#v",
+				tc.ExpectedOutput, output)
+		}
+	}
+}
+
+func TestResourceGhostAppCreateValidationIssues(t *testing.T) {
+	server := testGhostServer(nil, testGhostRoute{Status: 422, Body: `{
+		"_status": "ERR",
+		"_issues": {
+			"environment_infos": {"optional_volumes": {"1": {"iops": "must be of integer type"}}},
+			"env_vars": {"0": {"var_key": ["required field", "empty values not allowed"]}}
+		},
+		"_error": {"code": 422, "message": "Insertion failure: 1 document(s) contain(s) error(s)"}
+	}`})
+	defer server.Close()
+
+	diff := testGhostCreateDiff(resourceGhostApp(), func(d *schema.ResourceData) {
+		flattenGhostApp(d, app)
+	})
+	_, err := resourceGhostApp().Apply(nil, diff, testGhostMeta(server.URL))
+	if err == nil {
+		t.Fatalf("expected error, but got nil")
+	}
+
+	for _, expected := range []string{
+		"environment_infos.0.optional_volumes.1.iops: must be of integer type",
+		"environment_variables.0.key: required field; empty values not allowed",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("Unexpected error from resourceGhostAppCreate.\nExpected to contain: %s\nGiven: %v",
+				expected, err)
+		}
+	}
+}
+
+type testWrappedError struct {
+	err error
+}
+
+func (e testWrappedError) Error() string { return "wrapped: " + e.err.Error() }
+func (e testWrappedError) Unwrap() error { return e.err }
+
+func TestGhostIssuesErrorWrapped(t *testing.T) {
+	err := testWrappedError{&ghost.Error{
+		StatusCode: 422,
+		Issues:     map[string]string{"env_vars.0.var_key": "required field"},
+	}}
+
+	if !ghost.IsUnprocessableEntity(err) {
+		t.Fatalf("Expected wrapped error to be unprocessable: %v", err)
+	}
+
+	expected := "environment_variables.0.key: required field"
+	if output := ghostAppIssuesError(err); !strings.Contains(output.Error(), expected) {
+		t.Fatalf("Unexpected error from ghostAppIssuesError.\nExpected to contain: %s\nGiven: %v",
+			expected, output)
+	}
+}
+
+func TestResourceGhostAppReadRetries(t *testing.T) {
+	requests := []testGhostRequest{}
+	server := testGhostServer(&requests,
+		testGhostRoute{Times: 2, Status: 503},
+		testGhostRoute{Body: app},
+	)
+	defer server.Close()
+
+	state, err := resourceGhostApp().Refresh(&terraform.InstanceState{ID: "app_id"}, testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+	if len(requests) != 3 {
+		t.Fatalf("Unexpected number of attempts.\nExpected: 3\nGiven:    %d", len(requests))
+	}
+	if state.Attributes["name"] != app.Name {
+		t.Fatalf("Unexpected name after retried read: %s", state.Attributes["name"])
+	}
+}
+
+func TestResourceGhostAppCreateRetryNoDuplicate(t *testing.T) {
+	created := app
+	created.ID = "app_id"
+	etag := "etag"
+	created.Etag = &etag
+
+	requests := []testGhostRequest{}
+	server := testGhostServer(&requests,
+		// The app gets created but the gateway times out
+		testGhostRoute{Method: "POST", Status: 504},
+		testGhostRoute{Path: "/apps", Body: ghost.Apps{Items: []ghost.App{created}}},
+		testGhostRoute{Body: created},
+	)
+	defer server.Close()
+
+	diff := testGhostCreateDiff(resourceGhostApp(), func(d *schema.ResourceData) {
+		flattenGhostApp(d, app)
+	})
+	state, err := resourceGhostApp().Apply(nil, diff, testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+	if posts := len(testGhostRequestBodies(requests, "POST")); posts != 1 {
+		t.Fatalf("Unexpected number of POST requests.\nExpected: 1\nGiven:    %d", posts)
+	}
+	if state.ID != created.ID {
+		t.Fatalf("Unexpected ID after create.\nExpected: %#v\nGiven:    %#v", created.ID, state.ID)
+	}
+}
+
+func TestResourceGhostAppReadStopped(t *testing.T) {
+	release := make(chan struct{})
+	server := testGhostServer(nil, testGhostRoute{Handler: func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}})
+	defer server.Close()
+	defer close(release)
+
+	meta := testGhostMeta(server.URL)
+	ctx, stop := context.WithCancel(context.Background())
+	meta.stopContext = ctx
+	time.AfterFunc(50*time.Millisecond, stop)
+
+	done := make(chan error)
+	go func() {
+		_, err := resourceGhostApp().Refresh(&terraform.InstanceState{ID: "app_id"}, meta)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatalf("expected error, but got nil")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("resourceGhostAppRead did not return after the provider was stopped")
+	}
+}
+
+func TestFlattenGhostAppPendingChanges(t *testing.T) {
+	cases := []struct {
+		Input          *[]ghost.PendingChange
+		ExpectedOutput []interface{}
+	}{
+		{
+			&[]ghost.PendingChange{{Field: "features", Updated: "2018-05-04 10:22:31", User: "admin"}},
+			[]interface{}{
+				map[string]interface{}{
+					"field":   "features",
+					"updated": "2018-05-04 10:22:31",
+					"user":    "admin",
+				},
+			},
+		},
+		{
+			nil,
+			[]interface{}{},
+		},
+	}
+
+	for _, tc := range cases {
+		output := flattenGhostAppPendingChanges(tc.Input)
+		if !reflect.DeepEqual(output, tc.ExpectedOutput) {
+			t.Fatalf("Unexpected output from flattener.\nExpected: %#v\nGiven:    %#v",
+				tc.ExpectedOutput, output)
+		}
+	}
+}
+
+func TestGhostAppRequiredCommands(t *testing.T) {
+	cases := []struct {
+		Input          *[]ghost.PendingChange
+		ExpectedOutput []interface{}
+	}{
+		{
+			&[]ghost.PendingChange{
+				{Field: "modules"},
+				{Field: "features"},
+				{Field: "build_infos.source_ami"},
+				{Field: "lifecycle_hooks.pre_buildimage"},
+			},
+			[]interface{}{"buildimage", "redeploy"},
+		},
+		{
+			&[]ghost.PendingChange{
+				{Field: "lifecycle_hooks.pre_bootstrap"},
+				{Field: "autoscale"},
+				{Field: "unknown_field"},
+			},
+			[]interface{}{"updateautoscaling", "updatelifecyclehooks"},
+		},
+		{
+			nil,
+			[]interface{}{},
+		},
+	}
+
+	for _, tc := range cases {
+		output := ghostAppRequiredCommands(tc.Input)
+		if !reflect.DeepEqual(output, tc.ExpectedOutput) {
+			t.Fatalf("Unexpected output from ghostAppRequiredCommands.\nExpected: %#v\nGiven:    %#v",
+				tc.ExpectedOutput, output)
+		}
+	}
+}
+
+func TestResourceGhostAppReadPendingChanges(t *testing.T) {
+	pending := app
+	pending.PendingChanges = &[]ghost.PendingChange{
+		{Field: "features", Updated: "2018-05-04 10:22:31", User: "admin"},
+		{Field: "modules", Updated: "2018-05-04 10:23:02", User: "admin"},
+	}
+
+	server := testGhostServer(nil, testGhostRoute{Body: pending})
+	defer server.Close()
+
+	state, err := resourceGhostApp().Refresh(&terraform.InstanceState{ID: "app_id"}, testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+
+	expected := map[string]string{
+		"pending_changes.#":       "2",
+		"pending_changes.0.field": "features",
+		"pending_changes.1.user":  "admin",
+		"required_commands.#":     "2",
+		"required_commands." + strconv.Itoa(schema.HashString("buildimage")): "buildimage",
+		"required_commands." + strconv.Itoa(schema.HashString("redeploy")):   "redeploy",
+		"required_actions.#": "0",
+	}
+	for k, v := range expected {
+		if state.Attributes[k] != v {
+			t.Fatalf("Unexpected %s after resourceGhostAppRead.\nExpected: %#v\nGiven:    %#v",
+				k, v, state.Attributes[k])
+		}
+	}
+}
+
+// State of a ghost_app with the attributes of app and on_change enabled for
+// the given commands, if any
+func testGhostAppState(app ghost.App, commands ...string) *terraform.InstanceState {
+	d := resourceGhostApp().Data(nil)
+	flattenGhostApp(d, app)
+	enabled := map[string]interface{}{}
+	for _, command := range commands {
+		enabled[command] = true
+	}
+	d.Set("on_change", []interface{}{enabled})
+	d.Set("etag", "etag")
+	d.SetId("app_id")
+
+	return d.State()
+}
+
+func TestResourceGhostAppUpdateOnChange(t *testing.T) {
+	defer func(interval time.Duration) { ghostJobPollInterval = interval }(ghostJobPollInterval)
+	ghostJobPollInterval = time.Millisecond
+
+	resized := app
+	resized.InstanceType = "t2.large"
+	scaled := app
+	scaled.Autoscale = &ghost.Autoscale{Name: "autoscale", Max: 5}
+
+	cases := []struct {
+		Updated   ghost.App
+		Attribute string
+		Old       string
+		New       string
+	}{
+		{scaled, "autoscale.0.max", "3", "5"},
+		{resized, "instance_type", app.InstanceType, "t2.large"},
+	}
+
+	for _, tc := range cases {
+		updated := tc.Updated
+		etag := "etag2"
+		updated.Etag = &etag
+
+		requests := []testGhostRequest{}
+		server := testGhostServer(&requests, append(testGhostJobRoutes(ghost.Job{Status: "done"}, ""),
+			testGhostRoute{Method: "PATCH", Body: `{"_id": "app_id", "_etag": "etag2", "_status": "OK"}`},
+			testGhostRoute{Body: updated},
+		)...)
+
+		state := testGhostAppState(app, "buildimage", "updateautoscaling", "redeploy")
+		diff := &terraform.InstanceDiff{
+			Attributes: map[string]*terraform.ResourceAttrDiff{
+				tc.Attribute: {Old: tc.Old, New: tc.New},
+			},
+		}
+
+		newState, err := resourceGhostApp().Apply(state, diff, testGhostMeta(server.URL))
+		server.Close()
+		if err != nil {
+			t.Fatalf("expected no error, but got %s", err)
+		}
+
+		expectedJobs := []ghost.Job{{Command: "updateautoscaling", AppID: "app_id"}}
+		if posted := testGhostPostedJobs(requests); !reflect.DeepEqual(posted, expectedJobs) {
+			t.Fatalf("Unexpected jobs posted by resourceGhostAppUpdate of %s.\nExpected: %#v\nGiven:    %#v",
+				tc.Attribute, expectedJobs, posted)
+		}
+		if newState.Attributes[tc.Attribute] != tc.New || newState.Attributes["etag"] != "etag2" {
+			t.Fatalf("Unexpected state after resourceGhostAppUpdate: %#v", newState.Attributes)
+		}
+	}
+}
+
+func TestResourceGhostAppUpdateOnChangeTimeout(t *testing.T) {
+	defer func(interval time.Duration) { ghostJobPollInterval = interval }(ghostJobPollInterval)
+	ghostJobPollInterval = time.Millisecond
+
+	// The job runs longer than the update timeout, but not the on_change one
+	started := time.Now()
+	server := testGhostServer(nil,
+		testGhostRoute{Method: "PATCH", Body: `{"_id": "app_id", "_etag": "etag2", "_status": "OK"}`},
+		testGhostRoute{Method: "POST", Body: `{"_id": "job_id", "_status": "OK"}`},
+		testGhostRoute{Path: "/jobs/job_id", Handler: func(w http.ResponseWriter, r *http.Request) {
+			status := "running"
+			if time.Since(started) > 100*time.Millisecond {
+				status = "done"
+			}
+			json.NewEncoder(w).Encode(ghost.Job{Status: status})
+		}},
+		testGhostRoute{Body: app},
+	)
+	defer server.Close()
+
+	diff := &terraform.InstanceDiff{
+		Attributes: map[string]*terraform.ResourceAttrDiff{
+			"autoscale.0.max": {Old: "3", New: "5"},
+		},
+		Meta: map[string]interface{}{
+			schema.TimeoutKey: map[string]interface{}{schema.TimeoutUpdate: 50 * time.Millisecond},
+		},
+	}
+
+	_, err := resourceGhostApp().Apply(testGhostAppState(app, "updateautoscaling"), diff, testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+}
+
+func TestResourceGhostAppUpdateOnChangeFailed(t *testing.T) {
+	defer func(interval time.Duration) { ghostJobPollInterval = interval }(ghostJobPollInterval)
+	ghostJobPollInterval = time.Millisecond
+
+	deployed := app
+	deployed.Modules = &[]ghost.Module{(*app.Modules)[0]}
+	(*deployed.Modules)[0].LastDeployment = "deployment_id"
+	updated := deployed
+	updated.EnvironmentVariables = &[]ghost.EnvironmentVariable{{Key: "env_var_key", Value: "new_value"}}
+	etag := "etag2"
+	updated.Etag = &etag
+
+	requests := []testGhostRequest{}
+	server := testGhostServer(&requests, append(testGhostJobRoutes(ghost.Job{Status: "failed"}, "job failed\n"),
+		testGhostRoute{Method: "PATCH", Body: `{"_id": "app_id", "_etag": "etag2", "_status": "OK"}`},
+		testGhostRoute{Body: updated},
+	)...)
+	defer server.Close()
+
+	state := testGhostAppState(deployed, "buildimage", "redeploy")
+	diff := &terraform.InstanceDiff{
+		Attributes: map[string]*terraform.ResourceAttrDiff{
+			"environment_variables.0.value": {Old: "env_var_value", New: "new_value"},
+		},
+	}
+
+	newState, err := resourceGhostApp().Apply(state, diff, testGhostMeta(server.URL))
+	if err == nil {
+		t.Fatalf("expected error, but got nil")
+	}
+
+	expectedJobs := []ghost.Job{{Command: "redeploy", AppID: "app_id", Options: &[]string{"deployment_id"}}}
+	if posted := testGhostPostedJobs(requests); !reflect.DeepEqual(posted, expectedJobs) {
+		t.Fatalf("Unexpected jobs posted by resourceGhostAppUpdate.\nExpected: %#v\nGiven:    %#v",
+			expectedJobs, posted)
+	}
+
+	// The next apply must update the app and redeploy again
+	if newState.Attributes["environment_variables.0.value"] != "env_var_value" || newState.Attributes["etag"] != "etag2" {
+		t.Fatalf("Unexpected state after failed resourceGhostAppUpdate: %#v", newState.Attributes)
+	}
+}
+
+// Changes of a plan, as seen by ResourceDiff.HasChange
+// Changed attributes of a plan. Like with ResourceData, a block has changed
+// when one of its attributes has.
+type testGhostAppChanges []string
+
+func (c testGhostAppChanges) HasChange(key string) bool {
+	for _, changed := range c {
+		if changed == key || strings.HasPrefix(changed, key+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGhostAppRequiredActions(t *testing.T) {
+	cases := []struct {
+		Input          testGhostAppChanges
+		ExpectedOutput []string
+	}{
+		{
+			testGhostAppChanges{"description", "log_notifications"},
+			[]string{},
+		},
+		{
+			testGhostAppChanges{"build_infos.0.source_ami"},
+			[]string{"buildimage"},
+		},
+		{
+			testGhostAppChanges{"lifecycle_hooks.0.post_bootstrap"},
+			[]string{"updatelifecyclehooks"},
+		},
+		{
+			testGhostAppChanges{"instance_type"},
+			[]string{"updateautoscaling"},
+		},
+		{
+			testGhostAppChanges{"environment_variables", "lifecycle_hooks.0.pre_buildimage", "lifecycle_hooks.0.pre_bootstrap", "autoscale.0.max"},
+			[]string{"buildimage", "updatelifecyclehooks", "updateautoscaling", "redeploy"},
+		},
+	}
+
+	for _, tc := range cases {
+		output := ghostAppRequiredActions(tc.Input)
+		if !reflect.DeepEqual(output, tc.ExpectedOutput) {
+			t.Fatalf("Unexpected output from ghostAppRequiredActions.\nExpected: %#v\nGiven:    %#v",
+				tc.ExpectedOutput, output)
+		}
+	}
+}
+
+// The commands shown at plan time are the ones Cloud Deploy requires once the
+// change is applied
+func TestGhostAppRequiredActionsMatchRequiredCommands(t *testing.T) {
+	cases := []struct {
+		Attribute string
+		Field     string
+	}{
+		{"instance_type", "instance_type"},
+		{"environment_infos.0.key_name", "environment_infos.key_name"},
+		{"autoscale.0.max", "autoscale.max"},
+		{"build_infos.0.subnet_id", "build_infos.subnet_id"},
+		{"build_infos.0.source_ami", "build_infos.source_ami"},
+		{"features", "features"},
+		{"lifecycle_hooks.0.pre_buildimage", "lifecycle_hooks.pre_buildimage"},
+		{"lifecycle_hooks.0.post_buildimage", "lifecycle_hooks.post_buildimage"},
+		{"lifecycle_hooks.0.pre_bootstrap", "lifecycle_hooks.pre_bootstrap"},
+		{"lifecycle_hooks.0.post_bootstrap", "lifecycle_hooks.post_bootstrap"},
+		{"modules", "modules"},
+		{"environment_variables", "env_vars"},
+		{"description", "description"},
+	}
+
+	for _, tc := range cases {
+		actions := []interface{}{}
+		for _, action := range ghostAppRequiredActions(testGhostAppChanges{tc.Attribute}) {
+			actions = append(actions, action)
+		}
+		commands := ghostAppRequiredCommands(&[]ghost.PendingChange{{Field: tc.Field}})
+		if !reflect.DeepEqual(actions, commands) {
+			t.Fatalf("Unexpected required_actions for a change of %s.\nExpected: %#v\nGiven:    %#v",
+				tc.Attribute, commands, actions)
+		}
+	}
+}
+
+// Routes of an app updated on the server side since last plan: the first
+// write, sent with the outdated etag, fails with 412
+func testGhostAppConflictRoutes(current ghost.App) []testGhostRoute {
+	etag := "etag2"
+	current.ID = "app_id"
+	current.Etag = &etag
+
+	return []testGhostRoute{
+		{Method: "GET", Body: current},
+		{Times: 1, Status: 412, Body: `{"_status": "ERR", "_error": {"code": 412, "message": "Client and server etags don't match"}}`},
+		{Method: "DELETE", Status: 204},
+		{Body: `{"_id": "app_id", "_etag": "etag3", "_status": "OK"}`},
+	}
+}
+
+func TestResourceGhostAppUpdateConflictRebased(t *testing.T) {
+	current := app
+	current.Description = "Changed in the UI"
+	current.Modules = &[]ghost.Module{(*app.Modules)[0]}
+	(*current.Modules)[0].LastDeployment = "deployment_id"
+
+	cases := []struct {
+		FullDocument bool
+		Fields       []string
+	}{
+		// Only the changed fields are sent again: the change made in the UI is left as is
+		{false, []string{"autoscale"}},
+		// The whole document is sent again: it must carry the change made in the UI
+		{true, []string{"autoscale", "description", "env", "modules", "name", "role"}},
+	}
+
+	for _, tc := range cases {
+		requests := []testGhostRequest{}
+		server := testGhostServer(&requests, testGhostAppConflictRoutes(current)...)
+
+		diff := &terraform.InstanceDiff{
+			Attributes: map[string]*terraform.ResourceAttrDiff{
+				"autoscale.0.max": {Old: "3", New: "5"},
+			},
+		}
+
+		meta := testGhostMeta(server.URL)
+		meta.fullDocumentUpdates = tc.FullDocument
+
+		_, err := resourceGhostApp().Apply(testGhostAppState(app), diff, meta)
+		server.Close()
+		if err != nil {
+			t.Fatalf("expected no error, but got %s", err)
+		}
+
+		patches := testGhostRequestBodies(requests, "PATCH")
+		if len(patches) != 2 {
+			t.Fatalf("Unexpected number of PATCH requests.\nExpected: 2\nGiven:    %d", len(patches))
+		}
+		rebased := patches[1]
+		ifMatch := ""
+		for _, r := range requests {
+			if r.Method == "PATCH" {
+				ifMatch = r.Header.Get("If-Match")
+			}
+		}
+		if ifMatch != "etag2" {
+			t.Fatalf("Unexpected etag of rebased update.\nExpected: etag2\nGiven:    %s", ifMatch)
+		}
+		for _, field := range tc.Fields {
+			if _, ok := rebased[field]; !ok {
+				t.Fatalf("Expected field %s in rebased update: %v", field, rebased)
+			}
+		}
+		if !tc.FullDocument && len(rebased) != len(tc.Fields) {
+			t.Fatalf("Unexpected fields in rebased update.\nExpected: %v\nGiven:    %v", tc.Fields, rebased)
+		}
+		if tc.FullDocument && rebased["description"] != current.Description {
+			t.Fatalf("Unexpected description in rebased update.\nExpected: %s\nGiven:    %v",
+				current.Description, rebased["description"])
+		}
+		if autoscale, ok := rebased["autoscale"].(map[string]interface{}); !ok || autoscale["max"] != float64(5) {
+			t.Fatalf("Unexpected autoscale in rebased update: %v", rebased["autoscale"])
+		}
+	}
+}
+
+// Routes of the app, updated without conflict
+func testGhostAppFieldsRoutes() []testGhostRoute {
+	current := app
+	current.ID = "app_id"
+
+	return []testGhostRoute{
+		{Method: "GET", Body: current},
+		{Body: `{"_id": "app_id", "_etag": "etag3", "_status": "OK"}`},
+	}
+}
+
+func TestResourceGhostAppUpdateFields(t *testing.T) {
+	cases := []struct {
+		Attribute    string
+		Old, New     string
+		FullDocument bool
+		Fields       []string
+	}{
+		{"autoscale.0.max", "3", "5", false, []string{"autoscale"}},
+		{"autoscale.0.max", "3", "5", true, []string{"autoscale", "description", "env", "modules", "name", "role"}},
+		{"environment_variables.0.value", "env_var_value", "changed", false, []string{"env_vars"}},
+	}
+
+	for _, tc := range cases {
+		requests := []testGhostRequest{}
+		server := testGhostServer(&requests, testGhostAppFieldsRoutes()...)
+
+		diff := &terraform.InstanceDiff{
+			Attributes: map[string]*terraform.ResourceAttrDiff{
+				tc.Attribute: {Old: tc.Old, New: tc.New},
+			},
+		}
+
+		meta := testGhostMeta(server.URL)
+		meta.fullDocumentUpdates = tc.FullDocument
+
+		_, err := resourceGhostApp().Apply(testGhostAppState(app), diff, meta)
+		server.Close()
+		if err != nil {
+			t.Fatalf("expected no error, but got %s", err)
+		}
+
+		bodies := testGhostRequestBodies(requests, "PATCH")
+		if len(bodies) != 1 {
+			t.Fatalf("Unexpected number of PATCH requests.\nExpected: 1\nGiven:    %d", len(bodies))
+		}
+		for _, field := range tc.Fields {
+			if _, ok := bodies[0][field]; !ok {
+				t.Fatalf("Expected field %s in PATCH request: %v", field, bodies[0])
+			}
+		}
+		if !tc.FullDocument && len(bodies[0]) != len(tc.Fields) {
+			t.Fatalf("Unexpected fields in PATCH request.\nExpected: %v\nGiven:    %v", tc.Fields, bodies[0])
+		}
+	}
+}
+
+func TestResourceGhostAppUpdateRemovedBlock(t *testing.T) {
+	requests := []testGhostRequest{}
+	server := testGhostServer(&requests, testGhostAppFieldsRoutes()...)
+	defer server.Close()
+
+	diff := &terraform.InstanceDiff{
+		Attributes: map[string]*terraform.ResourceAttrDiff{
+			"blue_green.#": {Old: "1", New: "0"},
+		},
+	}
+
+	_, err := resourceGhostApp().Apply(testGhostAppState(app), diff, testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+
+	bodies := testGhostRequestBodies(requests, "PATCH")
+	if len(bodies) != 1 {
+		t.Fatalf("Unexpected number of PATCH requests.\nExpected: 1\nGiven:    %d", len(bodies))
+	}
+	if value, ok := bodies[0]["blue_green"]; !ok || value != nil || len(bodies[0]) != 1 {
+		t.Fatalf("Expected only a null blue_green in PATCH request: %v", bodies[0])
+	}
+}
+
+func TestResourceGhostAppUpdateOnChangeOnly(t *testing.T) {
+	requests := []testGhostRequest{}
+	server := testGhostServer(&requests, testGhostAppFieldsRoutes()...)
+	defer server.Close()
+
+	diff := &terraform.InstanceDiff{
+		Attributes: map[string]*terraform.ResourceAttrDiff{
+			"on_change.#":                      {Old: "0", New: "1"},
+			"on_change.0.buildimage":           {Old: "", New: "false"},
+			"on_change.0.redeploy":             {Old: "", New: "true"},
+			"on_change.0.updateautoscaling":    {Old: "", New: "false"},
+			"on_change.0.updatelifecyclehooks": {Old: "", New: "false"},
+		},
+	}
+
+	_, err := resourceGhostApp().Apply(testGhostAppState(app), diff, testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+
+	if bodies := testGhostRequestBodies(requests, "PATCH"); len(bodies) != 0 {
+		t.Fatalf("Unexpected PATCH requests: %v", bodies)
+	}
+}
+
+func TestGhostAppFieldNames(t *testing.T) {
+	expected := map[string]string{
+		"environment_variables": "env_vars",
+		"safe_deployment":       "safe-deployment",
+	}
+	if !reflect.DeepEqual(ghostAppFieldNames, expected) {
+		t.Fatalf("Unexpected ghostAppFieldNames.\nExpected: %#v\nGiven:    %#v", expected, ghostAppFieldNames)
+	}
+}
+
+func TestExpandGhostAppFields(t *testing.T) {
+	fields, err := expandGhostAppFields(app, []string{"description", "env_vars", "safe-deployment", "vpc_id"})
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+
+	if fields["description"] != app.Description {
+		t.Fatalf("Unexpected description.\nExpected: %s\nGiven:    %v", app.Description, fields["description"])
+	}
+	if _, ok := fields["env_vars"]; !ok {
+		t.Fatalf("Expected field env_vars in %v", fields)
+	}
+	if _, ok := fields["safe-deployment"]; !ok {
+		t.Fatalf("Expected field safe-deployment in %v", fields)
+	}
+	if len(fields) != 4 {
+		t.Fatalf("Unexpected fields: %v", fields)
+	}
+}
+
+func TestExpandGhostAppFieldsRemoved(t *testing.T) {
+	removed := app
+	removed.BlueGreen = nil
+
+	// Left out of the whole document, sent as null to clear it
+	fields, err := expandGhostAppFields(removed, []string{"blue_green"})
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+	if value, ok := fields["blue_green"]; !ok || value != nil {
+		t.Fatalf("Expected null blue_green in %v", fields)
+	}
+}
+
+func TestResourceGhostAppUpdateConflict(t *testing.T) {
+	current := app
+	current.Autoscale = &ghost.Autoscale{Name: "autoscale", Max: 4}
+
+	requests := []testGhostRequest{}
+	server := testGhostServer(&requests, testGhostAppConflictRoutes(current)...)
+	defer server.Close()
+
+	diff := &terraform.InstanceDiff{
+		Attributes: map[string]*terraform.ResourceAttrDiff{
+			"autoscale.0.max": {Old: "3", New: "5"},
+		},
+	}
+
+	_, err := resourceGhostApp().Apply(testGhostAppState(app), diff, testGhostMeta(server.URL))
+	if err == nil || !strings.Contains(err.Error(), "conflicting changes of autoscale") {
+		t.Fatalf("Unexpected error from resourceGhostAppUpdate: %v", err)
+	}
+	if patches := testGhostRequestBodies(requests, "PATCH"); len(patches) != 1 {
+		t.Fatalf("Unexpected number of PATCH requests.\nExpected: 1\nGiven:    %d", len(patches))
+	}
+}
+
+func TestResourceGhostAppDeleteRetried(t *testing.T) {
+	requests := []testGhostRequest{}
+	server := testGhostServer(&requests,
+		// The app gets deleted but the gateway times out: the retry finds it gone
+		testGhostRoute{Times: 1, Status: 504},
+		testGhostRoute{Status: 404, Body: `{"_status": "ERR", "_error": {"code": 404, "message": "The requested URL was not found on the server."}}`},
+	)
+	defer server.Close()
+
+	_, err := resourceGhostApp().Apply(testGhostAppState(app), &terraform.InstanceDiff{Destroy: true},
+		testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+	if deletes := testGhostRequestBodies(requests, "DELETE"); len(deletes) != 2 {
+		t.Fatalf("Unexpected number of DELETE requests.\nExpected: 2\nGiven:    %d", len(deletes))
+	}
+}
+
+func TestResourceGhostAppDeleteConflict(t *testing.T) {
+	deployed := app
+	deployed.Modules = &[]ghost.Module{(*app.Modules)[0]}
+	(*deployed.Modules)[0].LastDeployment = "deployment_id"
+	changed := app
+	changed.Description = "Changed in the UI"
+
+	cases := []struct {
+		Current          ghost.App
+		ExpectedDeletes  int
+		ExpectedConflict string
+	}{
+		{deployed, 2, ""},
+		{changed, 1, "conflicting changes of description"},
+	}
+
+	for _, tc := range cases {
+		requests := []testGhostRequest{}
+		server := testGhostServer(&requests, testGhostAppConflictRoutes(tc.Current)...)
+
+		_, err := resourceGhostApp().Apply(testGhostAppState(app), &terraform.InstanceDiff{Destroy: true},
+			testGhostMeta(server.URL))
+		server.Close()
+
+		if tc.ExpectedConflict == "" && err != nil {
+			t.Fatalf("expected no error, but got %s", err)
+		}
+		if tc.ExpectedConflict != "" && (err == nil || !strings.Contains(err.Error(), tc.ExpectedConflict)) {
+			t.Fatalf("Unexpected error from resourceGhostAppDelete: %v", err)
+		}
+		if deletes := testGhostRequestBodies(requests, "DELETE"); len(deletes) != tc.ExpectedDeletes {
+			t.Fatalf("Unexpected number of DELETE requests.\nExpected: %d\nGiven:    %d",
+				tc.ExpectedDeletes, len(deletes))
+		}
+	}
+}
+
+func TestGhostAppManagedValue(t *testing.T) {
+	modules := resourceGhostApp().Schema["modules"]
+	input := []interface{}{
+		map[string]interface{}{"name": "my_module", "last_deployment": "deployment_id"},
+	}
+	expected := []interface{}{
+		map[string]interface{}{"name": "my_module"},
+	}
+
+	output := ghostAppManagedValue(modules, input)
+	if !reflect.DeepEqual(output, expected) {
+		t.Fatalf("Unexpected output from ghostAppManagedValue.\nExpected: %#v\nGiven:    %#v",
+			expected, output)
+	}
+}
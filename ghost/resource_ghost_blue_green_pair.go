@@ -0,0 +1,360 @@
+package ghost
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+var ghostBlueGreenColors = []string{"blue", "green"}
+
+// The pair takes the ghost_app schema, except for the blue_green settings
+// which it manages for both apps and what is specific to each app
+func resourceGhostBlueGreenPair() *schema.Resource {
+	pairSchema := resourceGhostApp().Schema
+	for _, key := range []string{"blue_green", "etag", "on_change", "pending_changes", "required_commands", "required_actions", "image_fingerprint"} {
+		delete(pairSchema, key)
+	}
+
+	pairSchema["blue_green_hooks"] = &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		MaxItems: 1,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"post_swap": {
+					Type:     schema.TypeString,
+					Optional: true,
+				},
+				"pre_swap": {
+					Type:     schema.TypeString,
+					Optional: true,
+				},
+			},
+		},
+	}
+	for _, key := range []string{"blue_id", "green_id", "blue_etag", "green_etag", "online_color"} {
+		pairSchema[key] = &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		}
+	}
+	for _, key := range []string{"blue_drift", "green_drift"} {
+		pairSchema[key] = &schema.Schema{
+			Type:     schema.TypeList,
+			Computed: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+		}
+	}
+
+	return &schema.Resource{
+		Create: resourceGhostBlueGreenPairCreate,
+		Read:   resourceGhostBlueGreenPairRead,
+		Update: resourceGhostBlueGreenPairUpdate,
+		Delete: resourceGhostBlueGreenPairDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(1 * time.Minute),
+			Read:   schema.DefaultTimeout(1 * time.Minute),
+			Update: schema.DefaultTimeout(1 * time.Minute),
+			Delete: schema.DefaultTimeout(1 * time.Minute),
+		},
+
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		CustomizeDiff: resourceGhostAppBuildInfosCustomizeDiff,
+
+		Schema: pairSchema,
+	}
+}
+
+func resourceGhostBlueGreenPairCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutCreate))
+	defer cancel()
+
+	log.Printf("[INFO] Creating Ghost blue/green pair %s", d.Get("name").(string))
+	if err := validateGhostAppBuildInfos(expandGhostAppSettings(d).BuildInfos); err != nil {
+		return fmt.Errorf("[ERROR] error creating Ghost blue/green pair: %v", err)
+	}
+
+	blue, err := client.CreateAppWithContext(ctx, expandGhostBlueGreenPairApp(d, "blue", ""))
+	if err != nil {
+		if ghost.IsUnprocessableEntity(err) {
+			return fmt.Errorf("[ERROR] error creating Ghost blue app: %v", ghostAppIssuesError(err))
+		}
+		return fmt.Errorf("[ERROR] error creating Ghost blue app: %v", err)
+	}
+
+	green, err := client.CreateAppWithContext(ctx, expandGhostBlueGreenPairApp(d, "green", blue.ID))
+	if err != nil {
+		// Do not leave a blue app without its alter ego behind
+		if deleteErr := client.DeleteAppWithContext(ctx, blue.ID, *blue.Etag); deleteErr != nil {
+			log.Printf("[WARN] error deleting Ghost blue app %s: %v", blue.ID, deleteErr)
+		}
+		if ghost.IsUnprocessableEntity(err) {
+			return fmt.Errorf("[ERROR] error creating Ghost green app: %v", ghostAppIssuesError(err))
+		}
+		return fmt.Errorf("[ERROR] error creating Ghost green app: %v", err)
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s", blue.ID, green.ID))
+	d.Set("blue_etag", *blue.Etag)
+	d.Set("green_etag", *green.Etag)
+
+	// The green app only exists now: link the blue one to it
+	blueApp := expandGhostBlueGreenPairApp(d, "blue", green.ID)
+	blue, err = client.UpdateAppWithContext(ctx, &blueApp, blue.ID, *blue.Etag)
+	if err != nil {
+		return fmt.Errorf("[ERROR] error linking Ghost blue app to green app: %v", err)
+	}
+
+	d.Set("blue_etag", *blue.Etag)
+
+	return resourceGhostBlueGreenPairRead(d, meta)
+}
+
+func resourceGhostBlueGreenPairRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	log.Printf("[INFO] Reading Ghost blue/green pair %s", d.Id())
+
+	blueID, greenID, err := parseGhostBlueGreenPairID(d.Id())
+	if err != nil {
+		return fmt.Errorf("[ERROR] error reading Ghost blue/green pair: %v", err)
+	}
+
+	blue, green, err := getGhostBlueGreenPairApps(ctx, client, blueID, greenID)
+	if err != nil {
+		// If an app was not found, return nil to show that the pair is gone
+		if ghost.IsNotFound(err) {
+			log.Printf("[WARN] Ghost blue/green pair (%s) not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("[ERROR] error reading Ghost blue/green pair: %v", err)
+	}
+
+	if err := flattenGhostBlueGreenPairApps(d, blue, green); err != nil {
+		return fmt.Errorf("[ERROR] error reading Ghost blue/green pair: %v", err)
+	}
+	if drift := d.Get("blue_drift").([]interface{}); len(drift) > 0 {
+		log.Printf("[WARN] Ghost blue app %s of blue/green pair is out of sync on %v", blueID, drift)
+	}
+	if drift := d.Get("green_drift").([]interface{}); len(drift) > 0 {
+		log.Printf("[WARN] Ghost green app %s of blue/green pair is out of sync on %v", greenID, drift)
+	}
+
+	blueIsOnline := blue.BlueGreen != nil && blue.BlueGreen.IsOnline
+	greenIsOnline := green.BlueGreen != nil && green.BlueGreen.IsOnline
+	d.Set("blue_id", blueID)
+	d.Set("green_id", greenID)
+	d.Set("blue_etag", blue.Etag)
+	d.Set("green_etag", green.Etag)
+	d.Set("online_color", ghostBlueGreenOnlineColor(blueIsOnline, greenIsOnline))
+
+	return nil
+}
+
+func resourceGhostBlueGreenPairUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutUpdate))
+	defer cancel()
+
+	log.Printf("[INFO] Updating Ghost blue/green pair %s", d.Id())
+
+	blueID, greenID, err := parseGhostBlueGreenPairID(d.Id())
+	if err != nil {
+		return fmt.Errorf("[ERROR] error updating Ghost blue/green pair: %v", err)
+	}
+	if err := validateGhostAppBuildInfos(expandGhostAppSettings(d).BuildInfos); err != nil {
+		return fmt.Errorf("[ERROR] error updating Ghost blue/green pair: %v", err)
+	}
+
+	// Keep the previous settings in state if only one app is updated, so that
+	// the next plan updates both again
+	d.Partial(true)
+
+	for _, color := range ghostBlueGreenColors {
+		id, alterEgoID := blueID, greenID
+		if color == "green" {
+			id, alterEgoID = greenID, blueID
+		}
+
+		app := expandGhostBlueGreenPairApp(d, color, alterEgoID)
+		eveMetadata, err := client.UpdateAppWithContext(ctx, &app, id, d.Get(color+"_etag").(string))
+		if err != nil {
+			if ghost.IsPreconditionFailed(err) {
+				return fmt.Errorf(`[ERROR] error updating Ghost %s app: app has been updated since
+					last plan, you should run plan again: %v`, color, err)
+			}
+			if ghost.IsUnprocessableEntity(err) {
+				return fmt.Errorf("[ERROR] error updating Ghost %s app: %v", color, ghostAppIssuesError(err))
+			}
+			return fmt.Errorf("[ERROR] error updating Ghost %s app: %v", color, err)
+		}
+
+		d.Set(color+"_etag", *eveMetadata.Etag)
+		d.SetPartial(color + "_etag")
+	}
+
+	d.Partial(false)
+
+	return resourceGhostBlueGreenPairRead(d, meta)
+}
+
+func resourceGhostBlueGreenPairDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutDelete))
+	defer cancel()
+
+	log.Printf("[INFO] Deleting Ghost blue/green pair %s", d.Id())
+
+	blueID, greenID, err := parseGhostBlueGreenPairID(d.Id())
+	if err != nil {
+		return fmt.Errorf("[ERROR] error deleting Ghost blue/green pair: %v", err)
+	}
+
+	for color, id := range map[string]string{"blue": blueID, "green": greenID} {
+		err := client.DeleteAppWithContext(ctx, id, d.Get(color+"_etag").(string))
+		if err != nil && !ghost.IsNotFound(err) {
+			if ghost.IsPreconditionFailed(err) {
+				return fmt.Errorf(`[ERROR] error deleting Ghost %s app: app has been updated since
+					last destroy plan, you should run destroy plan again: %v`, color, err)
+			}
+			return fmt.Errorf("[ERROR] error deleting Ghost %s app: %v", color, err)
+		}
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// Pair IDs are the blue app ID and the green app ID, separated by a slash
+func parseGhostBlueGreenPairID(id string) (string, string, error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != 2 || !ghostAppIDRegexp.MatchString(parts[0]) || !ghostAppIDRegexp.MatchString(parts[1]) {
+		return "", "", fmt.Errorf("invalid Ghost blue/green pair ID %q: expected blue_app_id/green_app_id", id)
+	}
+	return parts[0], parts[1], nil
+}
+
+func getGhostBlueGreenPairApps(ctx context.Context, client *ghost.Client, blueID, greenID string) (blue, green ghost.App, err error) {
+	blue, err = client.GetAppWithContext(ctx, blueID)
+	if err != nil {
+		return
+	}
+	green, err = client.GetAppWithContext(ctx, greenID)
+	return
+}
+
+// Returns the managed attributes whose value differs between the apps of a
+// pair. Computed values, which are specific to each app, are left out.
+func ghostBlueGreenPairAppsDiff(blue, green ghost.App) []string {
+	pair := resourceGhostBlueGreenPair()
+	blueData, greenData := pair.Data(nil), pair.Data(nil)
+	flattenGhostBlueGreenPairApp(blueData, blue)
+	flattenGhostBlueGreenPairApp(greenData, green)
+
+	keys := []string{}
+	for key, attr := range pair.Schema {
+		if !attr.Optional && !attr.Required {
+			continue
+		}
+		if !reflect.DeepEqual(ghostAppManagedValue(attr, blueData.Get(key)), ghostAppManagedValue(attr, greenData.Get(key))) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Set the settings of both apps of the pair to TF state. Where the apps are
+// out of sync, each one is compared to the last read: the app which changed
+// since is listed in its drift attribute, and its value is kept so that the
+// plan brings it back to the configuration. The blue app is the reference
+// when there is no last read, e.g. on import.
+func flattenGhostBlueGreenPairApps(d *schema.ResourceData, blue, green ghost.App) error {
+	pair := resourceGhostBlueGreenPair()
+	keys := ghostBlueGreenPairAppsDiff(blue, green)
+	imported := d.Get("blue_etag").(string) == ""
+
+	last := map[string]interface{}{}
+	for _, key := range keys {
+		last[key] = d.Get(key)
+	}
+
+	if err := flattenGhostBlueGreenPairApp(d, blue); err != nil {
+		return err
+	}
+	greenData := pair.Data(nil)
+	if err := flattenGhostBlueGreenPairApp(greenData, green); err != nil {
+		return err
+	}
+
+	blueDrift, greenDrift := []string{}, []string{}
+	for _, key := range keys {
+		attr := pair.Schema[key]
+		lastValue := ghostAppManagedValue(attr, last[key])
+		if imported {
+			lastValue = ghostAppManagedValue(attr, d.Get(key))
+		}
+
+		blueDrifted := !reflect.DeepEqual(ghostAppManagedValue(attr, d.Get(key)), lastValue)
+		if blueDrifted {
+			blueDrift = append(blueDrift, key)
+		}
+		if !reflect.DeepEqual(ghostAppManagedValue(attr, greenData.Get(key)), lastValue) {
+			greenDrift = append(greenDrift, key)
+			if !blueDrifted {
+				if err := d.Set(key, greenData.Get(key)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	d.Set("blue_drift", blueDrift)
+	d.Set("green_drift", greenDrift)
+
+	return nil
+}
+
+// Get the app of color from TF configuration. Whether it is online is left
+// to the swap jobs: it is kept from the last read.
+func expandGhostBlueGreenPairApp(d *schema.ResourceData, color, alterEgoID string) ghost.App {
+	app := expandGhostAppSettings(d)
+	app.BlueGreen = &ghost.BlueGreen{
+		EnableBlueGreen: true,
+		Color:           color,
+		IsOnline:        d.Get("online_color").(string) == color,
+		AlterEgoID:      alterEgoID,
+		Hooks:           expandGhostAppBlueGreenHooks(d.Get("blue_green_hooks").([]interface{})),
+	}
+
+	return app
+}
+
+// Set the settings of an app of the pair to TF state
+func flattenGhostBlueGreenPairApp(d *schema.ResourceData, app ghost.App) error {
+	if app.BlueGreen != nil {
+		d.Set("blue_green_hooks", flattenGhostAppBlueGreenHooks(app.BlueGreen.Hooks))
+	} else {
+		d.Set("blue_green_hooks", nil)
+	}
+
+	return flattenGhostAppSettings(d, app)
+}
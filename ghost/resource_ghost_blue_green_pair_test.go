@@ -0,0 +1,379 @@
+package ghost
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccGhostBlueGreenPairBasic(t *testing.T) {
+	resourceName := "ghost_blue_green_pair.test"
+	envName := fmt.Sprintf("ghost_bg_pair_acc_env_basic_%s", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckGhostAppDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccGhostBlueGreenPairConfig(envName, "t2.micro"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet(resourceName, "blue_id"),
+					resource.TestCheckResourceAttrSet(resourceName, "green_id"),
+					resource.TestCheckResourceAttr(resourceName, "instance_type", "t2.micro"),
+				),
+			},
+			{
+				Config: testAccGhostBlueGreenPairConfig(envName, "t2.small"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "instance_type", "t2.small"),
+				),
+			},
+			{
+				ResourceName:      resourceName,
+				ImportState:       true,
+				ImportStateVerify: true,
+			},
+		},
+	})
+}
+
+func testAccGhostBlueGreenPairConfig(name, instanceType string) string {
+	return fmt.Sprintf(`
+      resource "ghost_blue_green_pair" "test" {
+        name = "%s"
+        env  = "prod"
+        role = "webfront"
+
+        region        = "eu-west-1"
+        instance_type = "%s"
+        vpc_id        = "vpc-3f1eb65a"
+
+        build_infos = {
+          subnet_id    = "subnet-a7e849fe"
+          ssh_username = "admin"
+          source_ami   = "ami-03ce4474"
+        }
+
+        environment_infos = {
+          instance_profile = "iam.ec2.demo"
+          key_name         = "ghost-demo"
+          subnet_ids       = ["subnet-a7e849fe"]
+          security_groups  = ["sg-6814f60c"]
+        }
+
+        modules = [{
+          name     = "wordpress"
+          path     = "/var/www"
+          scope    = "code"
+          git_repo = "https://github.com/KnpLabs/KnpIpsum.git"
+        }]
+      }
+      `, name, instanceType)
+}
+
+func TestParseGhostBlueGreenPairID(t *testing.T) {
+	cases := []struct {
+		Input         string
+		ExpectedBlue  string
+		ExpectedGreen string
+		ExpectedError bool
+	}{
+		{testBlueAppID + "/" + testGreenAppID, testBlueAppID, testGreenAppID, false},
+		{testBlueAppID, "", "", true},
+		{"blue/green", "", "", true},
+	}
+
+	for _, tc := range cases {
+		blue, green, err := parseGhostBlueGreenPairID(tc.Input)
+		if blue != tc.ExpectedBlue || green != tc.ExpectedGreen || (err != nil) != tc.ExpectedError {
+			t.Fatalf("Unexpected output from parseGhostBlueGreenPairID(%q): %q, %q, %v", tc.Input, blue, green, err)
+		}
+	}
+}
+
+func TestGhostBlueGreenPairAppsDiff(t *testing.T) {
+	green := app
+	green.BlueGreen = &ghost.BlueGreen{
+		EnableBlueGreen: true,
+		Color:           "green",
+		AlterEgoID:      testBlueAppID,
+		Hooks:           app.BlueGreen.Hooks,
+	}
+
+	// Each app has its own image and deployments
+	built := green
+	built.Ami = "ami-green"
+	buildInfos := *app.BuildInfos
+	buildInfos.AmiName = "ami.green"
+	buildInfos.ContainerImage = "green"
+	built.BuildInfos = &buildInfos
+	built.Modules = &[]ghost.Module{(*app.Modules)[0]}
+	(*built.Modules)[0].LastDeployment = "green_deployment_id"
+
+	resized := green
+	resized.InstanceType = "t2.large"
+
+	cases := []struct {
+		Green          ghost.App
+		ExpectedOutput []string
+	}{
+		{green, []string{}},
+		{built, []string{}},
+		{resized, []string{"instance_type"}},
+	}
+
+	for _, tc := range cases {
+		output := ghostBlueGreenPairAppsDiff(app, tc.Green)
+		if !reflect.DeepEqual(output, tc.ExpectedOutput) {
+			t.Fatalf("Unexpected output from ghostBlueGreenPairAppsDiff.\nExpected: %#v\nGiven:    %#v",
+				tc.ExpectedOutput, output)
+		}
+	}
+}
+
+// Mock of the apps API storing the apps of a pair, in creation order
+func testGhostBlueGreenPairHandler(apps map[string]ghost.App, patched map[string]ghost.App) http.HandlerFunc {
+	ids := []string{testBlueAppID, testGreenAppID}
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, "/apps/")
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/apps":
+			where := map[string]string{}
+			json.Unmarshal([]byte(r.URL.Query().Get("where")), &where)
+
+			items := []ghost.App{}
+			for _, id := range ids {
+				stored, ok := apps[id]
+				if !ok || stored.Name != where["name"] ||
+					(where["blue_green.color"] != "" && stored.BlueGreen.Color != where["blue_green.color"]) {
+					continue
+				}
+				etag := "etag_" + id
+				stored.ID = id
+				stored.Etag = &etag
+				items = append(items, stored)
+			}
+			json.NewEncoder(w).Encode(ghost.Apps{Items: items})
+		case r.Method == "POST":
+			var created ghost.App
+			json.NewDecoder(r.Body).Decode(&created)
+			id = ids[len(apps)]
+			apps[id] = created
+			w.Write([]byte(fmt.Sprintf(`{"_id": "%s", "_etag": "etag_%s", "_status": "OK"}`, id, id)))
+		case r.Method == "PATCH":
+			var updated ghost.App
+			json.NewDecoder(r.Body).Decode(&updated)
+			apps[id] = updated
+			patched[id] = updated
+			w.Write([]byte(fmt.Sprintf(`{"_id": "%s", "_etag": "etag2_%s", "_status": "OK"}`, id, id)))
+		default:
+			stored, ok := apps[id]
+			if !ok {
+				w.WriteHeader(404)
+				return
+			}
+			etag := "etag_" + id
+			stored.ID = id
+			stored.Etag = &etag
+			json.NewEncoder(w).Encode(stored)
+		}
+	}
+}
+
+// CRUD Unit Tests
+func TestResourceGhostBlueGreenPairCreate(t *testing.T) {
+	apps := map[string]ghost.App{}
+	patched := map[string]ghost.App{}
+	server := testGhostServer(nil, testGhostRoute{Handler: testGhostBlueGreenPairHandler(apps, patched)})
+	defer server.Close()
+
+	diff := testGhostCreateDiff(resourceGhostBlueGreenPair(), func(d *schema.ResourceData) {
+		flattenGhostBlueGreenPairApp(d, app)
+	})
+	state, err := resourceGhostBlueGreenPair().Apply(nil, diff, testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+
+	expectedBlueGreen := map[string]ghost.BlueGreen{
+		testBlueAppID:  {EnableBlueGreen: true, Color: "blue", AlterEgoID: testGreenAppID, Hooks: app.BlueGreen.Hooks},
+		testGreenAppID: {EnableBlueGreen: true, Color: "green", AlterEgoID: testBlueAppID, Hooks: app.BlueGreen.Hooks},
+	}
+	for id, expected := range expectedBlueGreen {
+		if !reflect.DeepEqual(*apps[id].BlueGreen, expected) {
+			t.Fatalf("Unexpected blue_green of app %s after resourceGhostBlueGreenPairCreate.\nExpected: %#v\nGiven:    %#v",
+				id, expected, *apps[id].BlueGreen)
+		}
+		if apps[id].InstanceType != app.InstanceType || !reflect.DeepEqual(apps[id].Modules, app.Modules) {
+			t.Fatalf("Unexpected settings of app %s after resourceGhostBlueGreenPairCreate: %#v", id, apps[id])
+		}
+	}
+	if _, ok := patched[testBlueAppID]; !ok {
+		t.Fatalf("Expected blue app to be linked to the green app")
+	}
+
+	expected := map[string]string{
+		"id":           testBlueAppID + "/" + testGreenAppID,
+		"blue_id":      testBlueAppID,
+		"green_id":     testGreenAppID,
+		"blue_etag":    "etag_" + testBlueAppID,
+		"green_etag":   "etag_" + testGreenAppID,
+		"online_color": "",
+	}
+	for k, v := range expected {
+		if state.Attributes[k] != v {
+			t.Fatalf("Unexpected %s after resourceGhostBlueGreenPairCreate.\nExpected: %#v\nGiven:    %#v",
+				k, v, state.Attributes[k])
+		}
+	}
+}
+
+func TestResourceGhostBlueGreenPairCreateRetry(t *testing.T) {
+	apps := map[string]ghost.App{}
+	patched := map[string]ghost.App{}
+	handler := testGhostBlueGreenPairHandler(apps, patched)
+
+	requests := []testGhostRequest{}
+	server := testGhostServer(&requests,
+		testGhostRoute{Method: "POST", Times: 1, Handler: handler},
+		// The green app gets created but the gateway times out
+		testGhostRoute{Method: "POST", Times: 1, Handler: func(w http.ResponseWriter, r *http.Request) {
+			handler(httptest.NewRecorder(), r)
+			w.WriteHeader(504)
+		}},
+		testGhostRoute{Handler: handler},
+	)
+	defer server.Close()
+
+	diff := testGhostCreateDiff(resourceGhostBlueGreenPair(), func(d *schema.ResourceData) {
+		flattenGhostBlueGreenPairApp(d, app)
+	})
+	state, err := resourceGhostBlueGreenPair().Apply(nil, diff, testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+
+	if posts := len(testGhostRequestBodies(requests, "POST")); posts != 2 {
+		t.Fatalf("Unexpected number of POST requests.\nExpected: 2\nGiven:    %d", posts)
+	}
+	if expected := testBlueAppID + "/" + testGreenAppID; state.ID != expected {
+		t.Fatalf("Unexpected ID after resourceGhostBlueGreenPairCreate.\nExpected: %#v\nGiven:    %#v", expected, state.ID)
+	}
+}
+
+func TestResourceGhostBlueGreenPairUpdate(t *testing.T) {
+	green := app
+	green.BlueGreen = &ghost.BlueGreen{EnableBlueGreen: true, Color: "green", AlterEgoID: testBlueAppID}
+	apps := map[string]ghost.App{testBlueAppID: app, testGreenAppID: green}
+	patched := map[string]ghost.App{}
+	server := testGhostServer(nil, testGhostRoute{Handler: testGhostBlueGreenPairHandler(apps, patched)})
+	defer server.Close()
+
+	state, err := resourceGhostBlueGreenPair().Refresh(&terraform.InstanceState{
+		ID: testBlueAppID + "/" + testGreenAppID,
+	}, testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+	if state.Attributes["online_color"] != "blue" {
+		t.Fatalf("Unexpected online_color after resourceGhostBlueGreenPairRead: %#v", state.Attributes["online_color"])
+	}
+
+	diff := &terraform.InstanceDiff{
+		Attributes: map[string]*terraform.ResourceAttrDiff{
+			"instance_type": {Old: "t2.micro", New: "t2.large"},
+		},
+	}
+
+	state, err = resourceGhostBlueGreenPair().Apply(state, diff, testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+
+	for id, color := range map[string]string{testBlueAppID: "blue", testGreenAppID: "green"} {
+		updated, ok := patched[id]
+		if !ok || updated.InstanceType != "t2.large" {
+			t.Fatalf("Expected %s app to be updated, got %#v", color, updated)
+		}
+		// Updates must not take the online app offline
+		if updated.BlueGreen.IsOnline != (color == "blue") {
+			t.Fatalf("Unexpected is_online of %s app after resourceGhostBlueGreenPairUpdate: %#v",
+				color, updated.BlueGreen.IsOnline)
+		}
+	}
+	if state.Attributes["instance_type"] != "t2.large" {
+		t.Fatalf("Unexpected instance_type after resourceGhostBlueGreenPairUpdate: %#v", state.Attributes["instance_type"])
+	}
+}
+
+func TestResourceGhostBlueGreenPairReadOutOfSync(t *testing.T) {
+	green := app
+	green.InstanceType = "t2.large"
+	green.BlueGreen = &ghost.BlueGreen{EnableBlueGreen: true, Color: "green", AlterEgoID: testBlueAppID, Hooks: app.BlueGreen.Hooks}
+	server := testGhostServer(nil, testGhostRoute{Handler: testGhostBlueGreenPairHandler(map[string]ghost.App{testBlueAppID: app, testGreenAppID: green}, nil)})
+	defer server.Close()
+
+	state, err := resourceGhostBlueGreenPair().Refresh(&terraform.InstanceState{
+		ID: testBlueAppID + "/" + testGreenAppID,
+	}, testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+
+	if state.Attributes["instance_type"] != "t2.large" {
+		t.Fatalf("Unexpected instance_type after resourceGhostBlueGreenPairRead.\nExpected: %#v\nGiven:    %#v",
+			"t2.large", state.Attributes["instance_type"])
+	}
+	if state.Attributes["green_drift.#"] != "1" || state.Attributes["green_drift.0"] != "instance_type" ||
+		state.Attributes["blue_drift.#"] != "0" {
+		t.Fatalf("Unexpected drift after resourceGhostBlueGreenPairRead: %#v", state.Attributes)
+	}
+}
+
+func TestResourceGhostBlueGreenPairReadDriftOfEachApp(t *testing.T) {
+	blue := app
+	blue.Description = "Changed on blue"
+	green := app
+	green.InstanceType = "t2.large"
+	green.BlueGreen = &ghost.BlueGreen{EnableBlueGreen: true, Color: "green", AlterEgoID: testBlueAppID, Hooks: app.BlueGreen.Hooks}
+	server := testGhostServer(nil, testGhostRoute{Handler: testGhostBlueGreenPairHandler(map[string]ghost.App{testBlueAppID: blue, testGreenAppID: green}, nil)})
+	defer server.Close()
+
+	// The pair as last read, in sync
+	d := resourceGhostBlueGreenPair().Data(nil)
+	flattenGhostBlueGreenPairApp(d, app)
+	d.SetId(testBlueAppID + "/" + testGreenAppID)
+	d.Set("blue_etag", "etag_"+testBlueAppID)
+	d.Set("green_etag", "etag_"+testGreenAppID)
+
+	state, err := resourceGhostBlueGreenPair().Refresh(d.State(), testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+
+	expected := map[string]string{
+		"description":   "Changed on blue",
+		"instance_type": "t2.large",
+		"blue_drift.#":  "1",
+		"blue_drift.0":  "description",
+		"green_drift.#": "1",
+		"green_drift.0": "instance_type",
+	}
+	for k, v := range expected {
+		if state.Attributes[k] != v {
+			t.Fatalf("Unexpected %s after resourceGhostBlueGreenPairRead.\nExpected: %#v\nGiven:    %#v",
+				k, v, state.Attributes[k])
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package ghost
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+func resourceGhostBlueGreenSwap() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceGhostBlueGreenSwapCreate,
+		Read:   resourceGhostBlueGreenSwapRead,
+		Update: resourceGhostBlueGreenSwapUpdate,
+		Delete: resourceGhostBlueGreenSwapDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(60 * time.Minute),
+			Read:   schema.DefaultTimeout(1 * time.Minute),
+			Update: schema.DefaultTimeout(60 * time.Minute),
+			Delete: schema.DefaultTimeout(1 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"blue_app_id": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: MatchesRegexp(`^[a-f0-9]{24}$`),
+			},
+			"green_app_id": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: MatchesRegexp(`^[a-f0-9]{24}$`),
+			},
+			"online_color": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ValidateFunc: validation.StringInSlice([]string{"blue", "green"}, false),
+			},
+			"purge": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"log_output_path": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"blue_is_online": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"green_is_online": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"job_ids": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func resourceGhostBlueGreenSwapCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutCreate))
+	defer cancel()
+
+	log.Printf("[INFO] Putting %s app of Ghost blue/green pair %s/%s online", d.Get("online_color").(string),
+		d.Get("blue_app_id").(string), d.Get("green_app_id").(string))
+
+	d.SetId(fmt.Sprintf("%s/%s", d.Get("blue_app_id").(string), d.Get("green_app_id").(string)))
+
+	if err := swapGhostBlueGreen(ctx, client, d); err != nil {
+		return fmt.Errorf("[ERROR] error swapping Ghost blue/green pair: %v", err)
+	}
+
+	return resourceGhostBlueGreenSwapRead(d, meta)
+}
+
+func resourceGhostBlueGreenSwapRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	log.Printf("[INFO] Reading Ghost blue/green pair %s", d.Id())
+
+	blue, green, err := getGhostBlueGreenApps(ctx, client, d)
+	if err != nil {
+		// If an app was not found, return nil to show that the pair is gone
+		if ghost.IsNotFound(err) {
+			log.Printf("[WARN] Ghost blue/green pair (%s) not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("[ERROR] error reading Ghost blue/green pair: %v", err)
+	}
+
+	blueIsOnline := blue.BlueGreen != nil && blue.BlueGreen.IsOnline
+	greenIsOnline := green.BlueGreen != nil && green.BlueGreen.IsOnline
+	d.Set("blue_is_online", blueIsOnline)
+	d.Set("green_is_online", greenIsOnline)
+	d.Set("online_color", ghostBlueGreenOnlineColor(blueIsOnline, greenIsOnline))
+
+	return nil
+}
+
+func resourceGhostBlueGreenSwapUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutUpdate))
+	defer cancel()
+
+	if d.HasChange("online_color") {
+		log.Printf("[INFO] Putting %s app of Ghost blue/green pair %s online", d.Get("online_color").(string), d.Id())
+
+		if err := swapGhostBlueGreen(ctx, client, d); err != nil {
+			return fmt.Errorf("[ERROR] error swapping Ghost blue/green pair: %v", err)
+		}
+	}
+
+	return resourceGhostBlueGreenSwapRead(d, meta)
+}
+
+// The apps stay as they are: destroying the resource only stops managing
+// which of them is online
+func resourceGhostBlueGreenSwapDelete(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[INFO] Removing Ghost blue/green pair %s from state", d.Id())
+
+	d.SetId("")
+
+	return nil
+}
+
+// Runs preparebluegreen then swapbluegreen on the app of online_color, unless
+// it is already online, then purgebluegreen on the other app if purge is set
+func swapGhostBlueGreen(ctx context.Context, client *ghost.Client, d *schema.ResourceData) error {
+	blue, green, err := getGhostBlueGreenApps(ctx, client, d)
+	if err != nil {
+		return err
+	}
+
+	online, offline := blue, green
+	if d.Get("online_color").(string) == "green" {
+		online, offline = green, blue
+	}
+
+	if online.BlueGreen == nil || !online.BlueGreen.EnableBlueGreen {
+		return fmt.Errorf("blue/green is not enabled on app %s", online.ID)
+	}
+	if online.BlueGreen.IsOnline {
+		log.Printf("[INFO] Ghost app %s is already online", online.ID)
+		return nil
+	}
+
+	logOutputPath := d.Get("log_output_path").(string)
+	jobIDs := []string{}
+	for _, job := range []ghost.Job{
+		{Command: "preparebluegreen", AppID: online.ID},
+		{Command: "swapbluegreen", AppID: online.ID},
+	} {
+		jobID, err := runGhostJob(ctx, client, job, ghostBlueGreenSwapLogPath(logOutputPath, job.Command))
+		if jobID != "" {
+			jobIDs = append(jobIDs, jobID)
+			d.Set("job_ids", jobIDs)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if d.Get("purge").(bool) {
+		job := ghost.Job{Command: "purgebluegreen", AppID: offline.ID}
+		jobID, err := runGhostJob(ctx, client, job, ghostBlueGreenSwapLogPath(logOutputPath, job.Command))
+		if jobID != "" {
+			jobIDs = append(jobIDs, jobID)
+			d.Set("job_ids", jobIDs)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Each job of a swap saves its log to its own file, named after its command:
+// swap.log becomes swap.preparebluegreen.log, swap.swapbluegreen.log...
+func ghostBlueGreenSwapLogPath(logOutputPath, command string) string {
+	if logOutputPath == "" {
+		return ""
+	}
+	ext := filepath.Ext(logOutputPath)
+	return strings.TrimSuffix(logOutputPath, ext) + "." + command + ext
+}
+
+func getGhostBlueGreenApps(ctx context.Context, client *ghost.Client, d *schema.ResourceData) (blue, green ghost.App, err error) {
+	blue, err = client.GetAppWithContext(ctx, d.Get("blue_app_id").(string))
+	if err != nil {
+		return
+	}
+	blue.ID = d.Get("blue_app_id").(string)
+
+	green, err = client.GetAppWithContext(ctx, d.Get("green_app_id").(string))
+	if err != nil {
+		return
+	}
+	green.ID = d.Get("green_app_id").(string)
+
+	return
+}
+
+// Returns the color of the online app of the pair, "" if none or both are
+func ghostBlueGreenOnlineColor(blueIsOnline, greenIsOnline bool) string {
+	switch {
+	case blueIsOnline && !greenIsOnline:
+		return "blue"
+	case greenIsOnline && !blueIsOnline:
+		return "green"
+	}
+	return ""
+}
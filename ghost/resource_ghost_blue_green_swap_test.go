@@ -0,0 +1,307 @@
+package ghost
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+const (
+	testBlueAppID  = "5accabf63d7eba00014e5679"
+	testGreenAppID = "5accabf63d7eba00014e567a"
+)
+
+func TestAccGhostBlueGreenSwapBasic(t *testing.T) {
+	resourceName := "ghost_blue_green_swap.test"
+	envName := fmt.Sprintf("ghost_bg_swap_acc_env_basic_%s", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckGhostAppDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccGhostBlueGreenSwapConfig(envName, "green"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "online_color", "green"),
+					resource.TestCheckResourceAttr(resourceName, "green_is_online", "true"),
+					resource.TestCheckResourceAttr(resourceName, "blue_is_online", "false"),
+				),
+			},
+			{
+				Config: testAccGhostBlueGreenSwapConfig(envName, "blue"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "online_color", "blue"),
+					resource.TestCheckResourceAttr(resourceName, "blue_is_online", "true"),
+					resource.TestCheckResourceAttr(resourceName, "green_is_online", "false"),
+				),
+			},
+		},
+	})
+}
+
+func testAccGhostBlueGreenSwapConfig(name, color string) string {
+	return fmt.Sprintf(`%s
+      %s
+      resource "ghost_blue_green_swap" "test" {
+        blue_app_id  = "${ghost_app.blue.id}"
+        green_app_id = "${ghost_app.green.id}"
+        online_color = "%s"
+      }
+      `, testAccGhostBlueGreenAppConfig(name, "blue"), testAccGhostBlueGreenAppConfig(name, "green"), color)
+}
+
+func testAccGhostBlueGreenAppConfig(name, color string) string {
+	return fmt.Sprintf(`
+      resource "ghost_app" "%[2]s" {
+        name = "%[1]s"
+        env  = "%[2]s"
+        role = "webfront"
+
+        region        = "eu-west-1"
+        instance_type = "t2.micro"
+        vpc_id        = "vpc-3f1eb65a"
+
+        build_infos = {
+          subnet_id    = "subnet-a7e849fe"
+          ssh_username = "admin"
+          source_ami   = "ami-03ce4474"
+        }
+
+        environment_infos = {
+          instance_profile = "iam.ec2.demo"
+          key_name         = "ghost-demo"
+          subnet_ids       = ["subnet-a7e849fe"]
+          security_groups  = ["sg-6814f60c"]
+        }
+
+        modules = [{
+          name     = "wordpress"
+          path     = "/var/www"
+          scope    = "code"
+          git_repo = "https://github.com/KnpLabs/KnpIpsum.git"
+        }]
+
+        blue_green = {
+          enable_blue_green = true
+          color             = "%[2]s"
+        }
+      }
+      `, name, color)
+}
+
+func TestGhostBlueGreenOnlineColor(t *testing.T) {
+	cases := []struct {
+		BlueIsOnline   bool
+		GreenIsOnline  bool
+		ExpectedOutput string
+	}{
+		{true, false, "blue"},
+		{false, true, "green"},
+		{false, false, ""},
+		{true, true, ""},
+	}
+
+	for _, tc := range cases {
+		output := ghostBlueGreenOnlineColor(tc.BlueIsOnline, tc.GreenIsOnline)
+		if output != tc.ExpectedOutput {
+			t.Fatalf("Unexpected output from ghostBlueGreenOnlineColor.\nExpected: %#v\nGiven:    %#v",
+				tc.ExpectedOutput, output)
+		}
+	}
+}
+
+// Routes of a blue/green pair whose online app changes on swapbluegreen jobs
+func testGhostBlueGreenRoutes(onlineAppID string) []testGhostRoute {
+	jobs := 0
+	return []testGhostRoute{
+		{Method: "POST", Handler: func(w http.ResponseWriter, r *http.Request) {
+			var job ghost.Job
+			json.NewDecoder(r.Body).Decode(&job)
+			jobs++
+			if job.Command == "swapbluegreen" {
+				onlineAppID = job.AppID
+			}
+			w.Write([]byte(fmt.Sprintf(`{"_id": "job_%d", "_status": "OK"}`, jobs)))
+		}},
+		{Path: "/jobs/", Body: ghost.Job{Status: "done"}},
+		{Path: "/apps/", Handler: func(w http.ResponseWriter, r *http.Request) {
+			id := strings.TrimPrefix(r.URL.Path, "/apps/")
+			json.NewEncoder(w).Encode(ghost.App{
+				BlueGreen: &ghost.BlueGreen{EnableBlueGreen: true, IsOnline: id == onlineAppID},
+			})
+		}},
+	}
+}
+
+// CRUD Unit Tests
+func TestResourceGhostBlueGreenSwapCreate(t *testing.T) {
+	defer func(interval time.Duration) { ghostJobPollInterval = interval }(ghostJobPollInterval)
+	ghostJobPollInterval = time.Millisecond
+
+	requests := []testGhostRequest{}
+	server := testGhostServer(&requests, testGhostBlueGreenRoutes(testBlueAppID)...)
+	defer server.Close()
+
+	diff := &terraform.InstanceDiff{
+		Attributes: map[string]*terraform.ResourceAttrDiff{
+			"blue_app_id":  {New: testBlueAppID},
+			"green_app_id": {New: testGreenAppID},
+			"online_color": {New: "green"},
+			"purge":        {New: "true"},
+		},
+	}
+
+	state, err := resourceGhostBlueGreenSwap().Apply(nil, diff, testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+
+	expectedJobs := []ghost.Job{
+		{Command: "preparebluegreen", AppID: testGreenAppID},
+		{Command: "swapbluegreen", AppID: testGreenAppID},
+		{Command: "purgebluegreen", AppID: testBlueAppID},
+	}
+	if posted := testGhostPostedJobs(requests); !reflect.DeepEqual(posted, expectedJobs) {
+		t.Fatalf("Unexpected jobs posted by resourceGhostBlueGreenSwapCreate.\nExpected: %#v\nGiven:    %#v",
+			expectedJobs, posted)
+	}
+
+	expected := map[string]string{
+		"id":              testBlueAppID + "/" + testGreenAppID,
+		"online_color":    "green",
+		"blue_is_online":  "false",
+		"green_is_online": "true",
+		"job_ids.#":       "3",
+		"job_ids.2":       "job_3",
+	}
+	for k, v := range expected {
+		if state.Attributes[k] != v {
+			t.Fatalf("Unexpected %s after resourceGhostBlueGreenSwapCreate.\nExpected: %#v\nGiven:    %#v",
+				k, v, state.Attributes[k])
+		}
+	}
+}
+
+func TestResourceGhostBlueGreenSwapCreateLogs(t *testing.T) {
+	defer func(interval time.Duration) { ghostJobPollInterval = interval }(ghostJobPollInterval)
+	ghostJobPollInterval = time.Millisecond
+
+	dir, err := ioutil.TempDir("", "ghost_blue_green_swap")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := testGhostServer(nil, testGhostBlueGreenRoutes(testBlueAppID)...)
+	defer server.Close()
+
+	diff := &terraform.InstanceDiff{
+		Attributes: map[string]*terraform.ResourceAttrDiff{
+			"blue_app_id":     {New: testBlueAppID},
+			"green_app_id":    {New: testGreenAppID},
+			"online_color":    {New: "green"},
+			"purge":           {New: "true"},
+			"log_output_path": {New: filepath.Join(dir, "swap.log")},
+		},
+	}
+
+	_, err = resourceGhostBlueGreenSwap().Apply(nil, diff, testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+
+	// One log per job, none overwritten by the next job
+	for _, name := range []string{"swap.preparebluegreen.log", "swap.swapbluegreen.log", "swap.purgebluegreen.log"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Fatalf("Expected job log %s: %s", name, err)
+		}
+	}
+}
+
+func TestGhostBlueGreenSwapLogPath(t *testing.T) {
+	cases := []struct {
+		Path           string
+		ExpectedOutput string
+	}{
+		{"/tmp/swap.log", "/tmp/swap.swapbluegreen.log"},
+		{"/tmp/swap", "/tmp/swap.swapbluegreen"},
+		{"", ""},
+	}
+
+	for _, tc := range cases {
+		output := ghostBlueGreenSwapLogPath(tc.Path, "swapbluegreen")
+		if output != tc.ExpectedOutput {
+			t.Fatalf("Unexpected output from ghostBlueGreenSwapLogPath(%q).\nExpected: %#v\nGiven:    %#v",
+				tc.Path, tc.ExpectedOutput, output)
+		}
+	}
+}
+
+func TestResourceGhostBlueGreenSwapCreateAlreadyOnline(t *testing.T) {
+	requests := []testGhostRequest{}
+	server := testGhostServer(&requests, testGhostBlueGreenRoutes(testGreenAppID)...)
+	defer server.Close()
+
+	diff := &terraform.InstanceDiff{
+		Attributes: map[string]*terraform.ResourceAttrDiff{
+			"blue_app_id":  {New: testBlueAppID},
+			"green_app_id": {New: testGreenAppID},
+			"online_color": {New: "green"},
+		},
+	}
+
+	state, err := resourceGhostBlueGreenSwap().Apply(nil, diff, testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+	if posted := testGhostPostedJobs(requests); len(posted) != 0 {
+		t.Fatalf("Unexpected jobs posted by resourceGhostBlueGreenSwapCreate: %#v", posted)
+	}
+	if state.Attributes["green_is_online"] != "true" {
+		t.Fatalf("Unexpected state after resourceGhostBlueGreenSwapCreate: %#v", state.Attributes)
+	}
+}
+
+func TestResourceGhostBlueGreenSwapReadDrift(t *testing.T) {
+	server := testGhostServer(nil, testGhostBlueGreenRoutes(testBlueAppID)...)
+	defer server.Close()
+
+	state, err := resourceGhostBlueGreenSwap().Refresh(&terraform.InstanceState{
+		ID: testBlueAppID + "/" + testGreenAppID,
+		Attributes: map[string]string{
+			"blue_app_id":     testBlueAppID,
+			"green_app_id":    testGreenAppID,
+			"online_color":    "green",
+			"blue_is_online":  "false",
+			"green_is_online": "true",
+		},
+	}, testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+
+	expected := map[string]string{
+		"online_color":    "blue",
+		"blue_is_online":  "true",
+		"green_is_online": "false",
+	}
+	for k, v := range expected {
+		if state.Attributes[k] != v {
+			t.Fatalf("Unexpected %s after resourceGhostBlueGreenSwapRead.\nExpected: %#v\nGiven:    %#v",
+				k, v, state.Attributes[k])
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package ghost
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+func resourceGhostDeployment() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceGhostDeploymentCreate,
+		Read:   resourceGhostDeploymentRead,
+		Update: resourceGhostDeploymentUpdate,
+		Delete: resourceGhostDeploymentDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+			Read:   schema.DefaultTimeout(1 * time.Minute),
+			Update: schema.DefaultTimeout(30 * time.Minute),
+			Delete: schema.DefaultTimeout(1 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"app_id": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: MatchesRegexp(`^[a-f0-9]{24}$`),
+			},
+			"modules": {
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 1,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"rev": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"deployment_id": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"commit": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"last_deployment": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"strategy": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "serial",
+				ValidateFunc: validation.StringInSlice([]string{"serial", "parallel"}, false),
+			},
+			"safe_deployment_strategy": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"1by1", "1/3", "25%", "50%"}, false),
+			},
+			"triggers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				ForceNew: true,
+			},
+			"log_output_path": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"job_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"deployment_ids": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+		},
+	}
+}
+
+func resourceGhostDeploymentCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutCreate))
+	defer cancel()
+
+	log.Printf("[INFO] Creating Ghost deployment on app %s", d.Get("app_id").(string))
+
+	modules := d.Get("modules").([]interface{})
+	jobID, err := runGhostDeployment(ctx, client, d, modules)
+	if jobID != "" {
+		d.SetId(jobID)
+	}
+	if err != nil {
+		return fmt.Errorf("[ERROR] error creating Ghost deployment: %v", err)
+	}
+
+	return resourceGhostDeploymentRead(d, meta)
+}
+
+func resourceGhostDeploymentRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	log.Printf("[INFO] Reading Ghost deployment %s", d.Id())
+
+	app, err := client.GetAppWithContext(ctx, d.Get("app_id").(string))
+	if err != nil {
+		// If app was not found, return nil to show that deployment is gone
+		if ghost.IsNotFound(err) {
+			log.Printf("[WARN] Ghost app (%s) not found, removing deployment from state", d.Get("app_id").(string))
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("[ERROR] error reading Ghost deployment: %v", err)
+	}
+
+	lastDeployments := map[string]string{}
+	if app.Modules != nil {
+		for _, module := range *app.Modules {
+			lastDeployments[module.Name] = module.LastDeployment
+		}
+	}
+
+	modules := d.Get("modules").([]interface{})
+	for _, config := range modules {
+		data := config.(map[string]interface{})
+		last := lastDeployments[data["name"].(string)]
+		data["last_deployment"] = last
+		if last == "" || last == data["deployment_id"].(string) {
+			continue
+		}
+
+		// Another revision has been deployed since: show it so the plan deploys
+		// the configured one again
+		deployment, err := client.GetDeploymentWithContext(ctx, last)
+		if err != nil {
+			if ghost.IsNotFound(err) {
+				continue
+			}
+			return fmt.Errorf("[ERROR] error reading Ghost deployment: %v", err)
+		}
+		log.Printf("[WARN] Module %s of Ghost app %s has been deployed at %s since",
+			data["name"].(string), app.ID, deployment.Revision)
+		data["rev"] = deployment.Revision
+		data["commit"] = deployment.Commit
+	}
+
+	if err := d.Set("modules", modules); err != nil {
+		return fmt.Errorf("[ERROR] error reading Ghost deployment: %v", err)
+	}
+
+	return nil
+}
+
+func resourceGhostDeploymentUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutUpdate))
+	defer cancel()
+
+	log.Printf("[INFO] Updating Ghost deployment %s", d.Id())
+
+	// Keep the previous revisions in state if the deployment fails
+	d.Partial(true)
+
+	if d.HasChange("modules") {
+		before, after := d.GetChange("modules")
+		modules := changedGhostDeploymentModules(before.([]interface{}), after.([]interface{}))
+		if len(modules) > 0 {
+			if _, err := runGhostDeployment(ctx, client, d, modules); err != nil {
+				return fmt.Errorf("[ERROR] error updating Ghost deployment: %v", err)
+			}
+		}
+	}
+
+	d.Partial(false)
+
+	return resourceGhostDeploymentRead(d, meta)
+}
+
+// Deployments are the history of the app: destroying the resource only
+// forgets the deployment, the modules stay deployed
+func resourceGhostDeploymentDelete(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[INFO] Removing Ghost deployment %s from state", d.Id())
+
+	d.SetId("")
+
+	return nil
+}
+
+// Runs a deploy job of the given modules, waits for it and records the
+// resulting deployments in the module list. Returns the ID of the job, if any.
+func runGhostDeployment(ctx context.Context, client *ghost.Client, d *schema.ResourceData, modules []interface{}) (string, error) {
+	job := ghost.Job{
+		Command: "deploy",
+		AppID:   d.Get("app_id").(string),
+		Modules: expandGhostJobModules(modules),
+		Options: expandGhostDeploymentOptions(d),
+	}
+
+	jobID, err := runGhostJob(ctx, client, job, d.Get("log_output_path").(string))
+	if err != nil {
+		return jobID, err
+	}
+
+	deployments, err := client.ListDeploymentsWithContext(ctx, &ghost.ListOptions{
+		Where: map[string]string{"job_id": jobID},
+	})
+	if err != nil {
+		return jobID, fmt.Errorf("error listing deployments of job %s: %v", jobID, err)
+	}
+
+	d.Set("job_id", jobID)
+	d.Set("modules", flattenGhostDeploymentModules(d.Get("modules").([]interface{}), deployments))
+	d.Set("deployment_ids", flattenGhostDeploymentIDs(d.Get("modules").([]interface{})))
+	d.SetPartial("modules")
+	d.SetPartial("job_id")
+	d.SetPartial("deployment_ids")
+
+	return jobID, nil
+}
+
+// Ghost deploy options are the execution strategy, then the safe deployment
+// split of the instances
+func expandGhostDeploymentOptions(d *schema.ResourceData) *[]string {
+	options := []string{d.Get("strategy").(string)}
+	if split := d.Get("safe_deployment_strategy").(string); split != "" {
+		options = append(options, split)
+	}
+	return &options
+}
+
+// Returns the modules of after whose revision is not the one in before
+func changedGhostDeploymentModules(before, after []interface{}) []interface{} {
+	revs := map[string]string{}
+	for _, config := range before {
+		data := config.(map[string]interface{})
+		revs[data["name"].(string)] = data["rev"].(string)
+	}
+
+	changed := []interface{}{}
+	for _, config := range after {
+		data := config.(map[string]interface{})
+		if rev, ok := revs[data["name"].(string)]; !ok || rev != data["rev"].(string) {
+			changed = append(changed, config)
+		}
+	}
+
+	return changed
+}
+
+// Sets the deployment of each module from the deployments of a job
+func flattenGhostDeploymentModules(modules []interface{}, deployments []ghost.Deployment) []interface{} {
+	byModule := map[string]ghost.Deployment{}
+	for _, deployment := range deployments {
+		byModule[deployment.Module] = deployment
+	}
+
+	values := []interface{}{}
+	for _, config := range modules {
+		data := config.(map[string]interface{})
+		if deployment, ok := byModule[data["name"].(string)]; ok {
+			data["deployment_id"] = deployment.ID
+			data["commit"] = deployment.Commit
+			data["last_deployment"] = deployment.ID
+		}
+		values = append(values, data)
+	}
+
+	return values
+}
+
+func flattenGhostDeploymentIDs(modules []interface{}) []interface{} {
+	ids := []interface{}{}
+	for _, config := range modules {
+		if id := config.(map[string]interface{})["deployment_id"].(string); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,258 @@
+package ghost
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccGhostDeploymentBasic(t *testing.T) {
+	resourceName := "ghost_deployment.test"
+	envName := fmt.Sprintf("ghost_deployment_acc_env_basic_%s", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckGhostAppDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccGhostDeploymentConfig(envName, "master"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "modules.0.rev", "master"),
+					resource.TestCheckResourceAttrSet(resourceName, "modules.0.commit"),
+					resource.TestCheckResourceAttrSet(resourceName, "modules.0.deployment_id"),
+					resource.TestCheckResourceAttr(resourceName, "deployment_ids.#", "1"),
+				),
+			},
+			{
+				Config: testAccGhostDeploymentConfig(envName, "HEAD~1"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "modules.0.rev", "HEAD~1"),
+					resource.TestCheckResourceAttrSet(resourceName, "modules.0.commit"),
+				),
+			},
+		},
+	})
+}
+
+func testAccGhostDeploymentConfig(name, rev string) string {
+	return fmt.Sprintf(`%s
+      resource "ghost_deployment" "test" {
+        app_id   = "${ghost_app.test.id}"
+        strategy = "parallel"
+
+        modules = [{
+          name = "wordpress"
+          rev  = "%s"
+        }]
+      }
+      `, testAccGhostAppConfig(name), rev)
+}
+
+func TestChangedGhostDeploymentModules(t *testing.T) {
+	old := []interface{}{
+		map[string]interface{}{"name": "wordpress", "rev": "v1"},
+		map[string]interface{}{"name": "config", "rev": "master"},
+	}
+
+	cases := []struct {
+		Input          []interface{}
+		ExpectedOutput []interface{}
+	}{
+		{
+			old,
+			[]interface{}{},
+		},
+		{
+			[]interface{}{
+				map[string]interface{}{"name": "wordpress", "rev": "v2"},
+				map[string]interface{}{"name": "config", "rev": "master"},
+				map[string]interface{}{"name": "assets", "rev": "master"},
+			},
+			[]interface{}{
+				map[string]interface{}{"name": "wordpress", "rev": "v2"},
+				map[string]interface{}{"name": "assets", "rev": "master"},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		output := changedGhostDeploymentModules(old, tc.Input)
+		if !reflect.DeepEqual(output, tc.ExpectedOutput) {
+			t.Fatalf("Unexpected output from changedGhostDeploymentModules.\nExpected: %#v\nGiven:    %#v",
+				tc.ExpectedOutput, output)
+		}
+	}
+}
+
+func TestExpandGhostDeploymentOptions(t *testing.T) {
+	cases := []struct {
+		Strategy       string
+		Split          string
+		ExpectedOutput *[]string
+	}{
+		{"serial", "", &[]string{"serial"}},
+		{"parallel", "1/3", &[]string{"parallel", "1/3"}},
+	}
+
+	for _, tc := range cases {
+		d := resourceGhostDeployment().Data(nil)
+		d.Set("strategy", tc.Strategy)
+		d.Set("safe_deployment_strategy", tc.Split)
+
+		output := expandGhostDeploymentOptions(d)
+		if !reflect.DeepEqual(output, tc.ExpectedOutput) {
+			t.Fatalf("Unexpected output from expander.\nExpected: %#v\nGiven:    %#v",
+				tc.ExpectedOutput, output)
+		}
+	}
+}
+
+// CRUD Unit Tests
+func TestResourceGhostDeploymentCreate(t *testing.T) {
+	defer func(interval time.Duration) { ghostJobPollInterval = interval }(ghostJobPollInterval)
+	ghostJobPollInterval = time.Millisecond
+
+	deployed := app
+	deployed.Modules = &[]ghost.Module{{Name: "wordpress", LastDeployment: "deployment_id"}}
+
+	requests := []testGhostRequest{}
+	server := testGhostServer(&requests, append(testGhostJobRoutes(ghost.Job{Command: "deploy", Status: "done"}, ""),
+		testGhostRoute{Path: "/deployments", Body: ghost.Deployments{Items: []ghost.Deployment{{
+			EveItemMetadata: ghost.EveItemMetadata{ID: "deployment_id"},
+			JobID:           "job_id",
+			Module:          "wordpress",
+			Revision:        "v2",
+			Commit:          "9f1a2b3c",
+		}}}},
+		testGhostRoute{Body: deployed},
+	)...)
+	defer server.Close()
+
+	diff := &terraform.InstanceDiff{
+		Attributes: map[string]*terraform.ResourceAttrDiff{
+			"app_id":                   {New: "5accabf63d7eba00014e5679"},
+			"strategy":                 {New: "parallel"},
+			"safe_deployment_strategy": {New: "25%"},
+			"modules.#":                {New: "1"},
+			"modules.0.name":           {New: "wordpress"},
+			"modules.0.rev":            {New: "v2"},
+		},
+	}
+
+	state, err := resourceGhostDeployment().Apply(nil, diff, testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+
+	expectedJob := ghost.Job{
+		Command: "deploy",
+		AppID:   "5accabf63d7eba00014e5679",
+		Modules: &[]ghost.JobModule{{Name: "wordpress", Rev: "v2"}},
+		Options: &[]string{"parallel", "25%"},
+	}
+	if posted := testGhostPostedJobs(requests); !reflect.DeepEqual(posted, []ghost.Job{expectedJob}) {
+		t.Fatalf("Unexpected job posted by resourceGhostDeploymentCreate.\nExpected: %#v\nGiven:    %#v",
+			expectedJob, posted)
+	}
+
+	expected := map[string]string{
+		"id":                        "job_id",
+		"job_id":                    "job_id",
+		"modules.0.deployment_id":   "deployment_id",
+		"modules.0.commit":          "9f1a2b3c",
+		"modules.0.last_deployment": "deployment_id",
+		"deployment_ids.0":          "deployment_id",
+	}
+	for k, v := range expected {
+		if state.Attributes[k] != v {
+			t.Fatalf("Unexpected %s after resourceGhostDeploymentCreate.\nExpected: %#v\nGiven:    %#v",
+				k, v, state.Attributes[k])
+		}
+	}
+}
+
+func TestResourceGhostDeploymentReadDrift(t *testing.T) {
+	redeployed := app
+	redeployed.Modules = &[]ghost.Module{{Name: "wordpress", LastDeployment: "other_deployment_id"}}
+
+	server := testGhostServer(nil,
+		testGhostRoute{Path: "/deployments/other_deployment_id",
+			Body: ghost.Deployment{Module: "wordpress", Revision: "hotfix", Commit: "0d1e2f3a"}},
+		testGhostRoute{Body: redeployed},
+	)
+	defer server.Close()
+
+	state, err := resourceGhostDeployment().Refresh(&terraform.InstanceState{
+		ID: "job_id",
+		Attributes: map[string]string{
+			"app_id":                    "5accabf63d7eba00014e5679",
+			"modules.#":                 "1",
+			"modules.0.name":            "wordpress",
+			"modules.0.rev":             "v2",
+			"modules.0.deployment_id":   "deployment_id",
+			"modules.0.commit":          "9f1a2b3c",
+			"modules.0.last_deployment": "deployment_id",
+		},
+	}, testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+
+	expected := map[string]string{
+		"modules.0.rev":             "hotfix",
+		"modules.0.commit":          "0d1e2f3a",
+		"modules.0.deployment_id":   "deployment_id",
+		"modules.0.last_deployment": "other_deployment_id",
+	}
+	for k, v := range expected {
+		if state.Attributes[k] != v {
+			t.Fatalf("Unexpected %s after resourceGhostDeploymentRead.\nExpected: %#v\nGiven:    %#v",
+				k, v, state.Attributes[k])
+		}
+	}
+}
+
+func TestResourceGhostDeploymentUpdateFailed(t *testing.T) {
+	defer func(interval time.Duration) { ghostJobPollInterval = interval }(ghostJobPollInterval)
+	ghostJobPollInterval = time.Millisecond
+
+	server := testGhostServer(nil,
+		testGhostRoute{Method: "POST", Body: `{"_id": "new_job_id", "_status": "OK"}`},
+		testGhostRoute{Path: "/jobs/new_job_id/logs", Body: "fatal: reference is not a tree: v3\n"},
+		testGhostRoute{Body: ghost.Job{Command: "deploy", Status: "failed"}},
+	)
+	defer server.Close()
+
+	state := &terraform.InstanceState{
+		ID: "job_id",
+		Attributes: map[string]string{
+			"app_id":                  "5accabf63d7eba00014e5679",
+			"strategy":                "serial",
+			"job_id":                  "job_id",
+			"modules.#":               "1",
+			"modules.0.name":          "wordpress",
+			"modules.0.rev":           "v2",
+			"modules.0.deployment_id": "deployment_id",
+		},
+	}
+	diff := &terraform.InstanceDiff{
+		Attributes: map[string]*terraform.ResourceAttrDiff{
+			"modules.0.rev": {Old: "v2", New: "v3"},
+		},
+	}
+
+	newState, err := resourceGhostDeployment().Apply(state, diff, testGhostMeta(server.URL))
+	if err == nil {
+		t.Fatalf("expected error, but got nil")
+	}
+	if newState.Attributes["modules.0.rev"] != "v2" || newState.Attributes["job_id"] != "job_id" {
+		t.Fatalf("Unexpected state after failed resourceGhostDeploymentUpdate: %#v", newState.Attributes)
+	}
+}
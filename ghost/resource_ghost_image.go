@@ -0,0 +1,227 @@
+package ghost
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/terraform/helper/hashcode"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func resourceGhostImage() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceGhostImageCreate,
+		Read:   resourceGhostImageRead,
+		Update: resourceGhostImageUpdate,
+		Delete: resourceGhostImageDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(60 * time.Minute),
+			Read:   schema.DefaultTimeout(1 * time.Minute),
+			Update: schema.DefaultTimeout(1 * time.Minute),
+			Delete: schema.DefaultTimeout(1 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"app_id": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: MatchesRegexp(`^[a-f0-9]{24}$`),
+			},
+			"instance_type": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"triggers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				ForceNew: true,
+			},
+			"log_output_path": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"ami_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"ami_name": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"job_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"source_ami": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"build_fingerprint": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Computed: true,
+				ForceNew: true,
+			},
+		},
+	}
+}
+
+func resourceGhostImageCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutCreate))
+	defer cancel()
+
+	appID := d.Get("app_id").(string)
+	log.Printf("[INFO] Building Ghost image of app %s", appID)
+
+	eveMetadata, err := client.CreateJobWithContext(ctx, ghost.Job{
+		Command:      "buildimage",
+		AppID:        appID,
+		InstanceType: d.Get("instance_type").(string),
+	})
+	if err != nil {
+		return fmt.Errorf("[ERROR] error creating Ghost image: %v", err)
+	}
+
+	// The image is identified by the job that built it
+	d.SetId(eveMetadata.ID)
+	d.Set("job_id", eveMetadata.ID)
+
+	job, err := waitGhostJob(ctx, client, d.Id())
+	if err != nil {
+		return fmt.Errorf("[ERROR] error waiting for Ghost image build job %s: %v", d.Id(), err)
+	}
+	if err := checkGhostJobResult(ctx, client, d.Id(), job, d.Get("log_output_path").(string)); err != nil {
+		d.SetId("")
+		return fmt.Errorf("[ERROR] error building Ghost image: %v", err)
+	}
+
+	app, err := client.GetAppWithContext(ctx, appID)
+	if err != nil {
+		return fmt.Errorf("[ERROR] error reading Ghost app %s after image build: %v", appID, err)
+	}
+	if app.Ami == "" || app.BuildInfos == nil {
+		return fmt.Errorf("[ERROR] Ghost image build job %s is done but app %s has no AMI", d.Id(), appID)
+	}
+
+	flattenGhostImage(d, app)
+
+	return nil
+}
+
+func resourceGhostImageRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	log.Printf("[INFO] Reading Ghost image %s", d.Get("ami_id").(string))
+
+	_, err := client.GetJobWithContext(ctx, d.Id())
+	if err != nil {
+		// If the build job was not found, return nil to show that image is gone
+		if ghost.IsNotFound(err) {
+			log.Printf("[WARN] Ghost image build job (%s) not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("[ERROR] error reading Ghost image: %v", err)
+	}
+
+	return nil
+}
+
+// Only log_output_path can change without building the image again
+func resourceGhostImageUpdate(d *schema.ResourceData, meta interface{}) error {
+	return resourceGhostImageRead(d, meta)
+}
+
+// AMIs are purged by Cloud Deploy according to the app retention settings:
+// destroying the resource only forgets the image
+func resourceGhostImageDelete(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[INFO] Removing Ghost image %s from state", d.Get("ami_id").(string))
+
+	d.SetId("")
+
+	return nil
+}
+
+// Set the image built from the app to TF state
+func flattenGhostImage(d *schema.ResourceData, app ghost.App) {
+	d.Set("ami_id", app.Ami)
+	d.Set("ami_name", app.BuildInfos.AmiName)
+	d.Set("source_ami", app.BuildInfos.SourceAmi)
+
+	// Keep the image_fingerprint of the app given in the configuration
+	if _, ok := d.GetOk("build_fingerprint"); !ok {
+		d.Set("build_fingerprint", ghostImageFingerprint(app))
+	}
+}
+
+// App attributes an image is built from: those whose changes need buildimage
+// in ghostAppPendingChangeCommands
+func ghostImageSourceAttributes() []string {
+	keys := []string{}
+	for _, field := range ghostAppCommandFields() {
+		for _, command := range ghostAppFieldCommands(field) {
+			if command == "buildimage" {
+				keys = append(keys, ghostAppAttributePath(field))
+			}
+		}
+	}
+
+	return keys
+}
+
+// Reports whether the changes of a plan of an app, from its ResourceData or
+// ResourceDiff, change the image it builds
+func ghostImageSourcesChanged(d interface {
+	HasChange(string) bool
+}) bool {
+	for _, key := range ghostImageSourceAttributes() {
+		if d.HasChange(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Hash of the app settings an image is built from. Only the values set by
+// configuration count: a build changes the computed ones, such as ami_name.
+func ghostImageFingerprint(app ghost.App) string {
+	d := resourceGhostApp().Data(nil)
+	flattenGhostAppSettings(d, app)
+
+	sources := map[string]interface{}{}
+	for _, key := range ghostImageSourceAttributes() {
+		sources[key] = ghostAppManagedValue(ghostAppAttributeSchema(key), d.Get(key))
+	}
+
+	data, _ := json.Marshal(sources)
+	return fmt.Sprintf("%d", hashcode.String(string(data)))
+}
+
+// Schema of a ghost_app attribute path, such as lifecycle_hooks.0.pre_buildimage
+func ghostAppAttributeSchema(key string) *schema.Schema {
+	elems := resourceGhostApp().Schema
+
+	var attr *schema.Schema
+	for _, name := range strings.Split(key, ".") {
+		if _, err := strconv.Atoi(name); err == nil {
+			continue
+		}
+		attr = elems[name]
+		if resource, ok := attr.Elem.(*schema.Resource); ok {
+			elems = resource.Schema
+		}
+	}
+
+	return attr
+}
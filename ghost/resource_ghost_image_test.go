@@ -0,0 +1,230 @@
+package ghost
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestAccGhostImageBasic(t *testing.T) {
+	resourceName := "ghost_image.test"
+	envName := fmt.Sprintf("ghost_image_acc_env_basic_%s", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckGhostAppDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccGhostImageConfig(envName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttrSet(resourceName, "ami_id"),
+					resource.TestCheckResourceAttrSet(resourceName, "ami_name"),
+					resource.TestCheckResourceAttrSet(resourceName, "job_id"),
+					resource.TestCheckResourceAttr(resourceName, "source_ami", "ami-03ce4474"),
+				),
+			},
+		},
+	})
+}
+
+func testAccGhostImageConfig(name string) string {
+	return fmt.Sprintf(`%s
+      resource "ghost_image" "test" {
+        app_id = "${ghost_app.test.id}"
+      }
+      `, testAccGhostAppConfig(name))
+}
+
+func TestGhostImageFingerprint(t *testing.T) {
+	built := app
+	fingerprint := ghostImageFingerprint(built)
+
+	sourceAmi := app
+	sourceAmi.BuildInfos = &ghost.BuildInfos{SourceAmi: "ami-12345678"}
+
+	features := app
+	features.Features = &[]ghost.Feature{{Name: "nginx", Version: "1.14", Provisioner: "ansible"}}
+
+	preBuildimage := app
+	preBuildimage.LifecycleHooks = &ghost.LifecycleHooks{PreBuildimage: StrToB64("#!/bin/bash")}
+
+	subnet := app
+	subnetBuildInfos := *app.BuildInfos
+	subnetBuildInfos.SubnetID = "subnet-12345678"
+	subnet.BuildInfos = &subnetBuildInfos
+
+	// Set by the build itself
+	rebuilt := app
+	rebuiltBuildInfos := *app.BuildInfos
+	rebuiltBuildInfos.AmiName = "ami.test.eu-west-1.webfront.test.2"
+	rebuilt.BuildInfos = &rebuiltBuildInfos
+	rebuilt.Ami = "ami-0a1b2c3d"
+
+	cases := []struct {
+		Input          ghost.App
+		ExpectedChange bool
+	}{
+		{built, false},
+		{sourceAmi, true},
+		{features, true},
+		{preBuildimage, true},
+		{subnet, true},
+		{rebuilt, false},
+	}
+
+	for _, tc := range cases {
+		output := ghostImageFingerprint(tc.Input) != fingerprint
+		if output != tc.ExpectedChange {
+			t.Fatalf("Unexpected output from ghostImageFingerprint.\nExpected change: %#v\nGiven:    %#v",
+				tc.ExpectedChange, output)
+		}
+	}
+}
+
+// CRUD Unit Tests
+func TestResourceGhostImageCreate(t *testing.T) {
+	defer func(interval time.Duration) { ghostJobPollInterval = interval }(ghostJobPollInterval)
+	ghostJobPollInterval = time.Millisecond
+
+	// Ghost stores the baked AMI on the app, and its name in build_infos
+	built := `{
+		"_id": "5accabf63d7eba00014e5679",
+		"name": "webfront",
+		"ami": "ami-0a1b2c3d",
+		"build_infos": {
+			"source_ami": "ami-03ce4474",
+			"ami_name": "ami.test.eu-west-1.webfront.test.1",
+			"ssh_username": "admin",
+			"subnet_id": "subnet-a7e849fe"
+		},
+		"modules": []
+	}`
+
+	server := testGhostServer(nil, append(testGhostJobRoutes(ghost.Job{Command: "buildimage", Status: "done"}, ""),
+		testGhostRoute{Body: built},
+	)...)
+	defer server.Close()
+
+	// Without a build_fingerprint, the one of the built app is kept
+	for _, fingerprint := range []string{"", "app_fingerprint"} {
+		diff := testGhostCreateDiff(resourceGhostImage(), func(d *schema.ResourceData) {
+			d.Set("app_id", "5accabf63d7eba00014e5679")
+			d.Set("build_fingerprint", fingerprint)
+		})
+		state, err := resourceGhostImage().Apply(nil, diff, testGhostMeta(server.URL))
+		if err != nil {
+			t.Fatalf("expected no error, but got %s", err)
+		}
+
+		expected := map[string]string{
+			"ami_id":            "ami-0a1b2c3d",
+			"ami_name":          "ami.test.eu-west-1.webfront.test.1",
+			"job_id":            "job_id",
+			"source_ami":        "ami-03ce4474",
+			"build_fingerprint": fingerprint,
+		}
+		if fingerprint == "" {
+			var builtApp ghost.App
+			json.Unmarshal([]byte(built), &builtApp)
+			expected["build_fingerprint"] = ghostImageFingerprint(builtApp)
+		}
+		for k, v := range expected {
+			if state.Attributes[k] != v {
+				t.Fatalf("Unexpected %s after resourceGhostImageCreate.\nExpected: %#v\nGiven:    %#v",
+					k, v, state.Attributes[k])
+			}
+		}
+	}
+}
+
+func TestResourceGhostImageCreateFailed(t *testing.T) {
+	defer func(interval time.Duration) { ghostJobPollInterval = interval }(ghostJobPollInterval)
+	ghostJobPollInterval = time.Millisecond
+
+	lines := []string{}
+	for i := 1; i <= 30; i++ {
+		lines = append(lines, fmt.Sprintf("log line %02d", i))
+	}
+
+	server := testGhostServer(nil, testGhostJobRoutes(
+		ghost.Job{Command: "buildimage", Status: "failed", Message: "packer failed"},
+		strings.Join(lines, "\n")+"\n",
+	)...)
+	defer server.Close()
+
+	diff := testGhostCreateDiff(resourceGhostImage(), func(d *schema.ResourceData) {
+		d.Set("app_id", "5accabf63d7eba00014e5679")
+	})
+	state, err := resourceGhostImage().Apply(nil, diff, testGhostMeta(server.URL))
+	if err == nil {
+		t.Fatalf("expected error, but got nil")
+	}
+	for _, expected := range []string{"packer failed", "log line 11", "log line 30"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Fatalf("Unexpected error from resourceGhostImageCreate.\nExpected to contain: %s\nGiven: %v",
+				expected, err)
+		}
+	}
+	if strings.Contains(err.Error(), "log line 10") {
+		t.Fatalf("Unexpected error from resourceGhostImageCreate, log excerpt is too long: %v", err)
+	}
+	if state != nil && state.ID != "" {
+		t.Fatalf("Unexpected state after failed resourceGhostImageCreate: %#v", state)
+	}
+}
+
+func TestGhostImageSourcesChanged(t *testing.T) {
+	cases := []struct {
+		Input          testGhostAppChanges
+		ExpectedOutput bool
+	}{
+		{testGhostAppChanges{"build_infos.0.source_ami"}, true},
+		{testGhostAppChanges{"features"}, true},
+		{testGhostAppChanges{"lifecycle_hooks.0.post_buildimage"}, true},
+		{testGhostAppChanges{"build_infos.0.subnet_id"}, true},
+		{testGhostAppChanges{"lifecycle_hooks.0.pre_bootstrap", "autoscale.0.max"}, false},
+	}
+
+	for _, tc := range cases {
+		output := ghostImageSourcesChanged(tc.Input)
+		if output != tc.ExpectedOutput {
+			t.Fatalf("Unexpected output from ghostImageSourcesChanged(%v).\nExpected: %#v\nGiven:    %#v",
+				tc.Input, tc.ExpectedOutput, output)
+		}
+	}
+}
+
+// The image is rebuilt for the changes ghost_app shows buildimage for
+func TestGhostImageSourcesMatchRequiredActions(t *testing.T) {
+	keys := []string{"description", "instance_type", "modules", "environment_variables"}
+	for _, field := range ghostAppCommandFields() {
+		keys = append(keys, ghostAppAttributePath(field))
+	}
+	for _, key := range []string{"build_infos", "environment_infos", "autoscale"} {
+		for sub, attr := range resourceGhostApp().Schema[key].Elem.(*schema.Resource).Schema {
+			if attr.Optional || attr.Required {
+				keys = append(keys, key+".0."+sub)
+			}
+		}
+	}
+
+	for _, key := range keys {
+		changes := testGhostAppChanges{key}
+		buildimage := false
+		for _, action := range ghostAppRequiredActions(changes) {
+			buildimage = buildimage || action == "buildimage"
+		}
+		if ghostImageSourcesChanged(changes) != buildimage {
+			t.Fatalf("Unexpected image rebuild for a change of %s.\nExpected: %#v\nGiven:    %#v",
+				key, buildimage, !buildimage)
+		}
+	}
+}
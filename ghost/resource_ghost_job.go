@@ -0,0 +1,382 @@
+package ghost
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"time"
+
+	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/terraform/helper/logging"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+// Commands accepted by the Cloud Deploy jobs endpoint
+var ghostJobCommands = []string{
+	"buildimage",
+	"createinstance",
+	"deploy",
+	"destroyallinstances",
+	"executescript",
+	"preparebluegreen",
+	"purgebluegreen",
+	"recreateinstances",
+	"redeploy",
+	"swapbluegreen",
+	"updateautoscaling",
+	"updatelifecyclehooks",
+}
+
+// Delay between two status checks of a running job
+var ghostJobPollInterval = 10 * time.Second
+
+func resourceGhostJob() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceGhostJobCreate,
+		Read:   resourceGhostJobRead,
+		Update: resourceGhostJobUpdate,
+		Delete: resourceGhostJobDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+			Read:   schema.DefaultTimeout(1 * time.Minute),
+			Update: schema.DefaultTimeout(1 * time.Minute),
+			Delete: schema.DefaultTimeout(1 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"command": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice(ghostJobCommands, false),
+			},
+			"app_id": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: MatchesRegexp(`^[a-f0-9]{24}$`),
+			},
+			"modules": {
+				Type:     schema.TypeList,
+				Optional: true,
+				ForceNew: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+							ForceNew: true,
+						},
+						"rev": {
+							Type:     schema.TypeString,
+							Optional: true,
+							ForceNew: true,
+						},
+					},
+				},
+			},
+			"options": {
+				Type:     schema.TypeList,
+				Optional: true,
+				ForceNew: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"instance_type": {
+				Type:     schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+			},
+			"triggers": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				ForceNew: true,
+			},
+			"log_output_path": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"status": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"message": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"log_id": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceGhostJobCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutCreate))
+	defer cancel()
+
+	log.Printf("[INFO] Creating Ghost job %s on app %s", d.Get("command").(string), d.Get("app_id").(string))
+	job := expandGhostJob(d)
+
+	eveMetadata, err := client.CreateJobWithContext(ctx, job)
+	if err != nil {
+		return fmt.Errorf("[ERROR] error creating Ghost job: %v", err)
+	}
+
+	d.SetId(eveMetadata.ID)
+
+	job, err = waitGhostJob(ctx, client, d.Id())
+	if err != nil {
+		return fmt.Errorf("[ERROR] error waiting for Ghost job %s: %v", d.Id(), err)
+	}
+
+	flattenGhostJob(d, job)
+
+	if err := checkGhostJobResult(ctx, client, d.Id(), job, d.Get("log_output_path").(string)); err != nil {
+		return fmt.Errorf("[ERROR] error running Ghost job: %v", err)
+	}
+
+	return nil
+}
+
+func resourceGhostJobRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	log.Printf("[INFO] Reading Ghost job %s", d.Id())
+
+	job, err := client.GetJobWithContext(ctx, d.Id())
+	if err != nil {
+		// If job was not found, return nil to show that job is gone
+		if ghost.IsNotFound(err) {
+			log.Printf("[WARN] Ghost job (%s) not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("[ERROR] error reading Ghost job: %v", err)
+	}
+
+	if err := flattenGhostJob(d, job); err != nil {
+		return fmt.Errorf("[ERROR] error reading Ghost job: %v", err)
+	}
+
+	return nil
+}
+
+// Only log_output_path can change without running the job again
+func resourceGhostJobUpdate(d *schema.ResourceData, meta interface{}) error {
+	return resourceGhostJobRead(d, meta)
+}
+
+// Jobs are the history of the commands run on an app: destroying the resource
+// only forgets the job, which is kept in Cloud Deploy
+func resourceGhostJobDelete(d *schema.ResourceData, meta interface{}) error {
+	log.Printf("[INFO] Removing Ghost job %s from state", d.Id())
+
+	d.SetId("")
+
+	return nil
+}
+
+// Runs the job, waits for it to end and checks it succeeded. Returns the ID
+// of the job, if it was created.
+func runGhostJob(ctx context.Context, client *ghost.Client, job ghost.Job, logOutputPath string) (string, error) {
+	eveMetadata, err := client.CreateJobWithContext(ctx, job)
+	if err != nil {
+		return "", fmt.Errorf("error creating %s job: %v", job.Command, err)
+	}
+	id := eveMetadata.ID
+
+	ended, err := waitGhostJob(ctx, client, id)
+	if err != nil {
+		return id, fmt.Errorf("error waiting for %s job %s: %v", job.Command, id, err)
+	}
+
+	return id, checkGhostJobResult(ctx, client, id, ended, logOutputPath)
+}
+
+// Polls the job until it ends or ctx is done. In debug mode, the job log is
+// streamed to the Terraform log as it grows.
+func waitGhostJob(ctx context.Context, client *ghost.Client, id string) (ghost.Job, error) {
+	ticker := time.NewTicker(ghostJobPollInterval)
+	defer ticker.Stop()
+
+	status := ""
+	streamed := 0
+	for {
+		job, err := client.GetJobWithContext(ctx, id)
+		if err != nil {
+			if ctx.Err() != nil && status != "" {
+				return job, fmt.Errorf("job still %s: %v", status, ctx.Err())
+			}
+			return job, err
+		}
+
+		finished := ghost.IsJobFinished(job.Status)
+		if logging.IsDebugOrHigher() {
+			streamed = streamGhostJobLog(ctx, client, id, streamed, finished)
+		}
+		if finished {
+			return job, nil
+		}
+		status = job.Status
+		log.Printf("[DEBUG] Ghost job %s is %s", id, status)
+
+		select {
+		case <-ctx.Done():
+			return job, fmt.Errorf("job still %s: %v", status, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// Logs the lines of the job log written after offset, and returns the offset
+// of the first line not logged yet. Only the log after offset is downloaded.
+// The last line is held back until it is complete, or the job is finished.
+func streamGhostJobLog(ctx context.Context, client *ghost.Client, id string, offset int, finished bool) int {
+	jobLog, err := client.GetJobLogFromWithContext(ctx, id, int64(offset))
+	if err != nil || jobLog == "" {
+		return offset
+	}
+
+	end := len(jobLog)
+	if !finished {
+		end = strings.LastIndex(jobLog, "\n") + 1
+		if end == 0 {
+			return offset
+		}
+	}
+
+	for _, line := range strings.Split(strings.TrimRight(jobLog[:end], "\n"), "\n") {
+		log.Printf("[DEBUG] Ghost job %s: %s", id, line)
+	}
+
+	return offset + end
+}
+
+// Number of trailing log lines reported when a job fails
+const ghostJobLogExcerptLines = 20
+
+// Saves the log of the ended job to logOutputPath, if set, and returns an
+// error ending with the last lines of the log if the job did not succeed
+func checkGhostJobResult(ctx context.Context, client *ghost.Client, id string, job ghost.Job, logOutputPath string) error {
+	jobLog := ""
+	if logOutputPath != "" || job.Status != ghost.JobStatusDone {
+		var err error
+		jobLog, err = client.GetJobLogWithContext(ctx, id)
+		if err != nil {
+			log.Printf("[WARN] Could not read the log of Ghost job %s: %v", id, err)
+		} else if logOutputPath != "" {
+			if err := ioutil.WriteFile(logOutputPath, []byte(jobLog), 0644); err != nil {
+				log.Printf("[WARN] Could not save the log of Ghost job %s: %v", id, err)
+			}
+		}
+	}
+
+	if job.Status == ghost.JobStatusDone {
+		return nil
+	}
+
+	msg := fmt.Sprintf("job %s ended with status %s: %s", id, job.Status, job.Message)
+	if excerpt := ghostJobLogExcerpt(jobLog); excerpt != "" {
+		msg = fmt.Sprintf("%s\n\nLast lines of the job log:\n%s", msg, excerpt)
+	}
+	return fmt.Errorf("%s", msg)
+}
+
+// Returns the last lines of the job log
+func ghostJobLogExcerpt(jobLog string) string {
+	jobLog = strings.TrimRight(jobLog, "\n")
+	if jobLog == "" {
+		return ""
+	}
+
+	lines := strings.Split(jobLog, "\n")
+	if len(lines) > ghostJobLogExcerptLines {
+		lines = lines[len(lines)-ghostJobLogExcerptLines:]
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Get job from TF configuration
+func expandGhostJob(d *schema.ResourceData) ghost.Job {
+	job := ghost.Job{
+		Command:      d.Get("command").(string),
+		AppID:        d.Get("app_id").(string),
+		Modules:      expandGhostJobModules(d.Get("modules").([]interface{})),
+		InstanceType: d.Get("instance_type").(string),
+	}
+
+	if options := expandGhostAppStringList(d.Get("options").([]interface{})); len(options) > 0 {
+		job.Options = &options
+	}
+
+	return job
+}
+
+// Set job to TF state
+func flattenGhostJob(d *schema.ResourceData, job ghost.Job) error {
+	d.Set("command", job.Command)
+	d.Set("app_id", job.AppID)
+	d.Set("instance_type", job.InstanceType)
+	d.Set("status", job.Status)
+	d.Set("message", job.Message)
+
+	// Cloud Deploy names job logs after the job unless it reports a log ID
+	logID := job.LogID
+	if logID == "" {
+		logID = job.ID
+	}
+	d.Set("log_id", logID)
+
+	if err := d.Set("modules", flattenGhostJobModules(job.Modules)); err != nil {
+		return err
+	}
+	if job.Options != nil {
+		if err := d.Set("options", flattenGhostAppStringList(*job.Options)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func expandGhostJobModules(d []interface{}) *[]ghost.JobModule {
+	if len(d) == 0 {
+		return nil
+	}
+
+	modules := []ghost.JobModule{}
+	for _, config := range d {
+		data := config.(map[string]interface{})
+		modules = append(modules, ghost.JobModule{
+			Name: data["name"].(string),
+			Rev:  data["rev"].(string),
+		})
+	}
+
+	return &modules
+}
+
+func flattenGhostJobModules(modules *[]ghost.JobModule) []interface{} {
+	if modules == nil {
+		return nil
+	}
+
+	values := []interface{}{}
+	for _, module := range *modules {
+		values = append(values, map[string]interface{}{
+			"name": module.Name,
+			"rev":  module.Rev,
+		})
+	}
+
+	return values
+}
@@ -0,0 +1,349 @@
+package ghost
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccGhostJobBasic(t *testing.T) {
+	resourceName := "ghost_job.test"
+	envName := fmt.Sprintf("ghost_job_acc_env_basic_%s", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckGhostAppDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccGhostJobConfig(envName),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "command", "updatelifecyclehooks"),
+					resource.TestCheckResourceAttr(resourceName, "status", "done"),
+					resource.TestCheckResourceAttrSet(resourceName, "log_id"),
+				),
+			},
+		},
+	})
+}
+
+func testAccGhostJobConfig(name string) string {
+	return fmt.Sprintf(`%s
+      resource "ghost_job" "test" {
+        command = "updatelifecyclehooks"
+        app_id  = "${ghost_app.test.id}"
+
+        triggers = {
+          etag = "${ghost_app.test.etag}"
+        }
+      }
+      `, testAccGhostAppConfig(name))
+}
+
+var (
+	job = ghost.Job{
+		Command: "deploy",
+		AppID:   "5accabf63d7eba00014e5679",
+		Modules: &[]ghost.JobModule{
+			{Name: "wordpress", Rev: "HEAD"},
+		},
+		Options:      &[]string{"false"},
+		InstanceType: "t2.micro",
+	}
+)
+
+// Expanders Unit Tests
+func TestExpandGhostJob(t *testing.T) {
+	d := resourceGhostJob().Data(nil)
+	d.Set("command", "deploy")
+	d.Set("app_id", "5accabf63d7eba00014e5679")
+	d.Set("modules", []interface{}{
+		map[string]interface{}{"name": "wordpress", "rev": "HEAD"},
+	})
+	d.Set("options", []interface{}{"false"})
+	d.Set("instance_type", "t2.micro")
+
+	output := expandGhostJob(d)
+	if !reflect.DeepEqual(output, job) {
+		t.Fatalf("Unexpected output from expander.\nExpected: %#v\nGiven:    %#v",
+			job, output)
+	}
+}
+
+func TestExpandGhostJobModules(t *testing.T) {
+	cases := []struct {
+		Input          []interface{}
+		ExpectedOutput *[]ghost.JobModule
+	}{
+		{
+			[]interface{}{
+				map[string]interface{}{"name": "wordpress", "rev": "HEAD"},
+				map[string]interface{}{"name": "config", "rev": ""},
+			},
+			&[]ghost.JobModule{
+				{Name: "wordpress", Rev: "HEAD"},
+				{Name: "config"},
+			},
+		},
+		{
+			nil,
+			nil,
+		},
+	}
+
+	for _, tc := range cases {
+		output := expandGhostJobModules(tc.Input)
+		if !reflect.DeepEqual(output, tc.ExpectedOutput) {
+			t.Fatalf("Unexpected output from expander.\nExpected: %#v\nGiven:    %#v",
+				tc.ExpectedOutput, output)
+		}
+	}
+}
+
+// Flatteners Unit Tests
+func TestFlattenGhostJobModules(t *testing.T) {
+	cases := []struct {
+		Input          *[]ghost.JobModule
+		ExpectedOutput []interface{}
+	}{
+		{
+			job.Modules,
+			[]interface{}{
+				map[string]interface{}{"name": "wordpress", "rev": "HEAD"},
+			},
+		},
+		{
+			nil,
+			nil,
+		},
+	}
+
+	for _, tc := range cases {
+		output := flattenGhostJobModules(tc.Input)
+		if !reflect.DeepEqual(output, tc.ExpectedOutput) {
+			t.Fatalf("Unexpected output from flattener.\nExpected: %#v\nGiven:    %#v",
+				tc.ExpectedOutput, output)
+		}
+	}
+}
+
+func TestGhostJobLogExcerpt(t *testing.T) {
+	long := []string{}
+	for i := 1; i <= 25; i++ {
+		long = append(long, fmt.Sprintf("line %d", i))
+	}
+
+	cases := []struct {
+		Input          string
+		ExpectedOutput string
+	}{
+		{"", ""},
+		{"\n", ""},
+		{"line 1\nline 2\n", "line 1\nline 2"},
+		{strings.Join(long, "\n"), strings.Join(long[5:], "\n")},
+	}
+
+	for _, tc := range cases {
+		output := ghostJobLogExcerpt(tc.Input)
+		if output != tc.ExpectedOutput {
+			t.Fatalf("Unexpected output from ghostJobLogExcerpt.\nExpected: %#v\nGiven:    %#v",
+				tc.ExpectedOutput, output)
+		}
+	}
+}
+
+func TestCheckGhostJobResult(t *testing.T) {
+	server := testGhostServer(nil, testGhostRoute{Body: "Cloning module wordpress\nfatal: reference is not a tree: v3\n"})
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "ghost_job_log")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cases := []struct {
+		Status        string
+		ExpectedError string
+	}{
+		{"done", ""},
+		{"failed", "job job_id ended with status failed: deploy failed\n\nLast lines of the job log:\n" +
+			"Cloning module wordpress\nfatal: reference is not a tree: v3"},
+	}
+
+	for _, tc := range cases {
+		path := filepath.Join(dir, tc.Status+".log")
+		job := ghost.Job{Status: tc.Status, Message: "deploy failed"}
+
+		err := checkGhostJobResult(context.Background(), testGhostMeta(server.URL).client, "job_id", job, path)
+		if tc.ExpectedError == "" && err != nil {
+			t.Fatalf("expected no error, but got %s", err)
+		}
+		if tc.ExpectedError != "" && (err == nil || err.Error() != tc.ExpectedError) {
+			t.Fatalf("Unexpected error from checkGhostJobResult.\nExpected: %s\nGiven:    %v", tc.ExpectedError, err)
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil || !strings.HasPrefix(string(data), "Cloning module wordpress") {
+			t.Fatalf("Unexpected log saved by checkGhostJobResult: %q, %v", data, err)
+		}
+	}
+}
+
+func TestStreamGhostJobLog(t *testing.T) {
+	// Whether the server honours Range headers or sends the whole log
+	for _, ranges := range []bool{true, false} {
+		jobLog := "step 1\nstep 2\nstep"
+		requests := []testGhostRequest{}
+		server := testGhostServer(&requests, testGhostRoute{Handler: func(w http.ResponseWriter, r *http.Request) {
+			if ranges {
+				http.ServeContent(w, r, "", time.Time{}, strings.NewReader(jobLog))
+				return
+			}
+			w.Write([]byte(jobLog))
+		}})
+
+		var output bytes.Buffer
+		log.SetOutput(&output)
+
+		client := testGhostMeta(server.URL).client
+
+		offset := streamGhostJobLog(context.Background(), client, "job_id", 0, false)
+		offset = streamGhostJobLog(context.Background(), client, "job_id", offset, false)
+		jobLog += " 3\n"
+		offset = streamGhostJobLog(context.Background(), client, "job_id", offset, false)
+		offset = streamGhostJobLog(context.Background(), client, "job_id", offset, true)
+
+		log.SetOutput(os.Stderr)
+		server.Close()
+
+		if offset != len(jobLog) {
+			t.Fatalf("Unexpected offset after streamGhostJobLog.\nExpected: %d\nGiven:    %d", len(jobLog), offset)
+		}
+		requested := []string{}
+		for _, r := range requests {
+			requested = append(requested, r.Header.Get("Range"))
+		}
+		expectedRanges := []string{"", "bytes=14-", "bytes=14-", "bytes=21-"}
+		if !reflect.DeepEqual(requested, expectedRanges) {
+			t.Fatalf("Unexpected ranges requested.\nExpected: %v\nGiven:    %v", expectedRanges, requested)
+		}
+		for _, expected := range []string{"step 1", "step 2", "step 3"} {
+			if n := strings.Count(output.String(), "[DEBUG] Ghost job job_id: "+expected+"\n"); n != 1 {
+				t.Fatalf("Unexpected streamed log, %q logged %d times:\n%s", expected, n, output.String())
+			}
+		}
+	}
+}
+
+// CRUD Unit Tests
+func TestResourceGhostJobCreate(t *testing.T) {
+	defer func(interval time.Duration) { ghostJobPollInterval = interval }(ghostJobPollInterval)
+	ghostJobPollInterval = time.Millisecond
+
+	cases := []struct {
+		Statuses        []string
+		ExpectedStatus  string
+		ExpectedError   bool
+		ExpectedMessage string
+	}{
+		{[]string{"init", "started", "done"}, "done", false, ""},
+		{[]string{"started", "failed"}, "failed", true, "ended with status failed: deploy failed"},
+		{[]string{"aborted"}, "aborted", true, "ended with status aborted"},
+	}
+
+	for _, tc := range cases {
+		routes := []testGhostRoute{{Method: "POST", Body: `{"_id": "job_id", "_status": "OK"}`}}
+		for _, status := range tc.Statuses {
+			running := job
+			running.ID = "job_id"
+			running.Status = status
+			if status == "failed" {
+				running.Message = "deploy failed"
+			}
+			routes = append(routes, testGhostRoute{Path: "/jobs/job_id", Times: 1, Body: running})
+		}
+		requests := []testGhostRequest{}
+		server := testGhostServer(&requests, routes...)
+
+		diff := testGhostCreateDiff(resourceGhostJob(), func(d *schema.ResourceData) {
+			flattenGhostJob(d, job)
+		})
+		state, err := resourceGhostJob().Apply(nil, diff, testGhostMeta(server.URL))
+		server.Close()
+
+		if (err != nil) != tc.ExpectedError {
+			t.Fatalf("Unexpected error from resourceGhostJobCreate with statuses %v: %v", tc.Statuses, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), tc.ExpectedMessage) {
+			t.Fatalf("Unexpected error from resourceGhostJobCreate.\nExpected to contain: %s\nGiven: %v",
+				tc.ExpectedMessage, err)
+		}
+		gets := 0
+		for _, r := range requests {
+			if r.Path == "/jobs/job_id" {
+				gets++
+			}
+		}
+		if gets != len(tc.Statuses) {
+			t.Fatalf("Unexpected number of job polls.\nExpected: %d\nGiven:    %d", len(tc.Statuses), gets)
+		}
+		if state.ID != "job_id" || state.Attributes["status"] != tc.ExpectedStatus {
+			t.Fatalf("Unexpected state after resourceGhostJobCreate: %#v", state)
+		}
+		if state.Attributes["log_id"] != "job_id" {
+			t.Fatalf("Unexpected log_id after resourceGhostJobCreate: %s", state.Attributes["log_id"])
+		}
+	}
+}
+
+func TestResourceGhostJobCreateTimeout(t *testing.T) {
+	defer func(interval time.Duration) { ghostJobPollInterval = interval }(ghostJobPollInterval)
+	ghostJobPollInterval = time.Millisecond
+
+	server := testGhostServer(nil,
+		testGhostRoute{Method: "POST", Body: `{"_id": "job_id", "_status": "OK"}`},
+		testGhostRoute{Body: `{"_id": "job_id", "status": "started"}`},
+	)
+	defer server.Close()
+
+	diff := testGhostCreateDiff(resourceGhostJob(), func(d *schema.ResourceData) {
+		flattenGhostJob(d, job)
+	})
+	diff.Meta = map[string]interface{}{
+		schema.TimeoutKey: map[string]interface{}{schema.TimeoutCreate: 50 * time.Millisecond},
+	}
+
+	_, err := resourceGhostJob().Apply(nil, diff, testGhostMeta(server.URL))
+	if err == nil || !strings.Contains(err.Error(), "job still started") {
+		t.Fatalf("Unexpected error from resourceGhostJobCreate: %v", err)
+	}
+}
+
+func TestResourceGhostJobReadNotFound(t *testing.T) {
+	server := testGhostServer(nil, testGhostRoute{Status: 404, Body: `{"_status": "ERR", "_error": {"code": 404, "message": "Not Found"}}`})
+	defer server.Close()
+
+	state, err := resourceGhostJob().Refresh(&terraform.InstanceState{ID: "job_id"}, testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+	if state != nil {
+		t.Fatalf("Unexpected state after resourceGhostJobRead of a missing job: %#v", state)
+	}
+}
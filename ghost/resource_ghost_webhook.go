@@ -0,0 +1,221 @@
+package ghost
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+func resourceGhostWebhook() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceGhostWebhookCreate,
+		Read:   resourceGhostWebhookRead,
+		Update: resourceGhostWebhookUpdate,
+		Delete: resourceGhostWebhookDelete,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(1 * time.Minute),
+			Read:   schema.DefaultTimeout(1 * time.Minute),
+			Update: schema.DefaultTimeout(1 * time.Minute),
+			Delete: schema.DefaultTimeout(1 * time.Minute),
+		},
+
+		Importer: &schema.ResourceImporter{
+			State: schema.ImportStatePassthrough,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"app_id": {
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: MatchesRegexp(`^[a-f0-9]{24}$`),
+			},
+			"module": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"rev": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"commands": {
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 1,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validation.StringInSlice(ghostJobCommands, false),
+				},
+			},
+			"safe_deployment_strategy": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"1by1", "1/3", "25%", "50%"}, false),
+			},
+			"instance_type": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"secret": {
+				Type:      schema.TypeString,
+				Optional:  true,
+				Sensitive: true,
+			},
+			"url": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"etag": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func resourceGhostWebhookCreate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutCreate))
+	defer cancel()
+
+	log.Printf("[INFO] Creating Ghost webhook for module %s of app %s", d.Get("module").(string), d.Get("app_id").(string))
+	webhook := expandGhostWebhook(d)
+
+	eveMetadata, err := client.CreateWebhookWithContext(ctx, webhook)
+	if err != nil {
+		if ghost.IsUnprocessableEntity(err) {
+			return fmt.Errorf("[ERROR] error creating Ghost webhook: %v", ghostWebhookIssuesError(err))
+		}
+		return fmt.Errorf("[ERROR] error creating Ghost webhook: %v", err)
+	}
+
+	d.Set("etag", *eveMetadata.Etag)
+	d.SetId(eveMetadata.ID)
+
+	return resourceGhostWebhookRead(d, meta)
+}
+
+func resourceGhostWebhookRead(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutRead))
+	defer cancel()
+
+	log.Printf("[INFO] Reading Ghost webhook %s", d.Id())
+
+	webhook, err := client.GetWebhookWithContext(ctx, d.Id())
+	if err != nil {
+		// If webhook was not found, return nil to show that webhook is gone
+		if ghost.IsNotFound(err) {
+			log.Printf("[WARN] Ghost webhook (%s) not found, removing from state", d.Id())
+			d.SetId("")
+			return nil
+		}
+		return fmt.Errorf("[ERROR] error reading Ghost webhook: %v", err)
+	}
+
+	if err := flattenGhostWebhook(d, webhook); err != nil {
+		return fmt.Errorf("[ERROR] error reading Ghost webhook: %v", err)
+	}
+
+	return nil
+}
+
+func resourceGhostWebhookUpdate(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutUpdate))
+	defer cancel()
+
+	log.Printf("[INFO] Updating Ghost webhook %s", d.Id())
+
+	webhook := expandGhostWebhook(d)
+
+	eveMetadata, err := client.UpdateWebhookWithContext(ctx, &webhook, d.Id(), d.Get("etag").(string))
+	if err != nil {
+		if ghost.IsPreconditionFailed(err) {
+			return fmt.Errorf(`[ERROR] error updating Ghost webhook: webhook has been updated since
+				last plan, you should run plan again: %v`, err)
+		}
+		if ghost.IsUnprocessableEntity(err) {
+			return fmt.Errorf("[ERROR] error updating Ghost webhook: %v", ghostWebhookIssuesError(err))
+		}
+		return fmt.Errorf("[ERROR] error updating Ghost webhook: %v", err)
+	}
+
+	d.Set("etag", *eveMetadata.Etag)
+
+	return resourceGhostWebhookRead(d, meta)
+}
+
+func resourceGhostWebhookDelete(d *schema.ResourceData, meta interface{}) error {
+	client := meta.(*providerMeta).client
+	ctx, cancel := meta.(*providerMeta).timeoutContext(d.Timeout(schema.TimeoutDelete))
+	defer cancel()
+
+	log.Printf("[INFO] Deleting Ghost webhook %s", d.Id())
+
+	err := client.DeleteWebhookWithContext(ctx, d.Id(), d.Get("etag").(string))
+
+	// The webhook is gone, such as when a retried request went through the first time
+	if err != nil && !ghost.IsNotFound(err) {
+		if ghost.IsPreconditionFailed(err) {
+			return fmt.Errorf(`[ERROR] error deleting Ghost webhook: webhook has been updated since
+				last destroy plan, you should run destroy plan again: %v`, err)
+		}
+		return fmt.Errorf("[ERROR] error deleting Ghost webhook: %v", err)
+	}
+
+	d.SetId("")
+
+	return nil
+}
+
+// Turn the Eve validation issues of an API error into one error per
+// Terraform attribute
+func ghostWebhookIssuesError(err error) error {
+	return ghostIssuesError(err, func(field string) string {
+		if field == "secret_token" {
+			return "secret"
+		}
+		return field
+	})
+}
+
+// Get webhook from TF configuration
+func expandGhostWebhook(d *schema.ResourceData) ghost.Webhook {
+	return ghost.Webhook{
+		AppID:                  d.Get("app_id").(string),
+		Module:                 d.Get("module").(string),
+		Rev:                    d.Get("rev").(string),
+		Commands:               expandGhostAppStringList(d.Get("commands").([]interface{})),
+		SafeDeploymentStrategy: d.Get("safe_deployment_strategy").(string),
+		InstanceType:           d.Get("instance_type").(string),
+		SecretToken:            d.Get("secret").(string),
+	}
+}
+
+// Set webhook to TF state
+func flattenGhostWebhook(d *schema.ResourceData, webhook ghost.Webhook) error {
+	d.Set("app_id", webhook.AppID)
+	d.Set("module", webhook.Module)
+	d.Set("rev", webhook.Rev)
+	d.Set("safe_deployment_strategy", webhook.SafeDeploymentStrategy)
+	d.Set("instance_type", webhook.InstanceType)
+	d.Set("url", webhook.URL)
+	d.Set("etag", webhook.Etag)
+
+	// The secret is not sent back by every Cloud Deploy version
+	if webhook.SecretToken != "" {
+		d.Set("secret", webhook.SecretToken)
+	}
+
+	if err := d.Set("commands", flattenGhostAppStringList(webhook.Commands)); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,219 @@
+package ghost
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cloud-deploy.io/cloud-deploy-sdk-go"
+	"github.com/hashicorp/terraform/helper/acctest"
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestAccGhostWebhookBasic(t *testing.T) {
+	resourceName := "ghost_webhook.test"
+	envName := fmt.Sprintf("ghost_webhook_acc_env_basic_%s", acctest.RandString(10))
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:     func() { testAccPreCheck(t) },
+		Providers:    testAccProviders,
+		CheckDestroy: testAccCheckGhostWebhookDestroy,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccGhostWebhookConfig(envName, "master"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "rev", "master"),
+					resource.TestCheckResourceAttr(resourceName, "commands.#", "2"),
+					resource.TestCheckResourceAttrSet(resourceName, "url"),
+				),
+			},
+			{
+				Config: testAccGhostWebhookConfig(envName, "develop"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr(resourceName, "rev", "develop"),
+				),
+			},
+			{
+				ResourceName:            resourceName,
+				ImportState:             true,
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"secret"},
+			},
+		},
+	})
+}
+
+func testAccGhostWebhookConfig(name, rev string) string {
+	return fmt.Sprintf(`%s
+      resource "ghost_webhook" "test" {
+        app_id   = "${ghost_app.test.id}"
+        module   = "wordpress"
+        rev      = "%s"
+        commands = ["buildimage", "deploy"]
+        secret   = "s3cr3t"
+
+        safe_deployment_strategy = "1/3"
+      }
+      `, testAccGhostAppConfig(name), rev)
+}
+
+func testAccCheckGhostWebhookDestroy(s *terraform.State) error {
+	client := testAccProvider.Meta().(*providerMeta).client
+
+	for _, rs := range s.RootModule().Resources {
+		if rs.Type != "ghost_webhook" {
+			continue
+		}
+
+		_, err := client.GetWebhook(rs.Primary.ID)
+		if err == nil {
+			return fmt.Errorf("[INFO] Ghost webhook still exists: %s", rs.Primary.ID)
+		}
+		if !ghost.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return testAccCheckGhostAppDestroy(s)
+}
+
+var (
+	webhook = ghost.Webhook{
+		AppID:                  "5accabf63d7eba00014e5679",
+		Module:                 "wordpress",
+		Rev:                    "master",
+		Commands:               []string{"buildimage", "deploy"},
+		SafeDeploymentStrategy: "1/3",
+		InstanceType:           "t2.micro",
+		SecretToken:            "s3cr3t",
+	}
+)
+
+func TestExpandFlattenGhostWebhook(t *testing.T) {
+	d := resourceGhostWebhook().Data(nil)
+	if err := flattenGhostWebhook(d, webhook); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	output := expandGhostWebhook(d)
+	if !reflect.DeepEqual(output, webhook) {
+		t.Fatalf("Unexpected output from expander.\nExpected: %#v\nGiven:    %#v",
+			webhook, output)
+	}
+}
+
+// CRUD Unit Tests
+func TestResourceGhostWebhookCreate(t *testing.T) {
+	etag := "etag"
+	created := webhook
+	created.ID = "webhook_id"
+	created.Etag = &etag
+	created.SecretToken = ""
+	created.URL = "https://ghost.internal/webhooks/webhook_id"
+
+	requests := []testGhostRequest{}
+	server := testGhostServer(&requests,
+		testGhostRoute{Method: "POST", Body: `{"_id": "webhook_id", "_etag": "etag", "_status": "OK"}`},
+		testGhostRoute{Body: created},
+	)
+	defer server.Close()
+
+	diff := testGhostCreateDiff(resourceGhostWebhook(), func(d *schema.ResourceData) {
+		flattenGhostWebhook(d, webhook)
+	})
+	state, err := resourceGhostWebhook().Apply(nil, diff, testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+	var posted ghost.Webhook
+	requests[0].Decode(&posted)
+	if !reflect.DeepEqual(posted, webhook) {
+		t.Fatalf("Unexpected webhook posted.\nExpected: %#v\nGiven:    %#v", webhook, posted)
+	}
+
+	expected := map[string]string{
+		"id":     "webhook_id",
+		"etag":   "etag",
+		"url":    "https://ghost.internal/webhooks/webhook_id",
+		"secret": "s3cr3t",
+	}
+	for k, v := range expected {
+		if state.Attributes[k] != v {
+			t.Fatalf("Unexpected %s after resourceGhostWebhookCreate.\nExpected: %#v\nGiven:    %#v",
+				k, v, state.Attributes[k])
+		}
+	}
+}
+
+func TestResourceGhostWebhookDeleteRetried(t *testing.T) {
+	requests := []testGhostRequest{}
+	server := testGhostServer(&requests,
+		// The webhook gets deleted but the gateway times out: the retry finds it gone
+		testGhostRoute{Times: 1, Status: 504},
+		testGhostRoute{Status: 404, Body: `{"_status": "ERR", "_error": {"code": 404, "message": "The requested URL was not found on the server."}}`},
+	)
+	defer server.Close()
+
+	d := resourceGhostWebhook().Data(nil)
+	flattenGhostWebhook(d, webhook)
+	d.Set("etag", "etag")
+	d.SetId("webhook_id")
+
+	_, err := resourceGhostWebhook().Apply(d.State(), &terraform.InstanceDiff{Destroy: true},
+		testGhostMeta(server.URL))
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+	if deletes := testGhostRequestBodies(requests, "DELETE"); len(deletes) != 2 {
+		t.Fatalf("Unexpected number of DELETE requests.\nExpected: 2\nGiven:    %d", len(deletes))
+	}
+}
+
+func TestResourceGhostWebhookUpdateErrors(t *testing.T) {
+	cases := []struct {
+		StatusCode    int
+		Body          string
+		ExpectedError string
+	}{
+		{412, `{"_status": "ERR", "_error": {"code": 412, "message": "Client and server etags don't match"}}`,
+			"webhook has been updated since"},
+		{422, `{"_status": "ERR", "_issues": {"secret_token": "min length is 8"}, "_error": {"code": 422}}`,
+			"secret: min length is 8"},
+	}
+
+	for _, tc := range cases {
+		requests := []testGhostRequest{}
+		server := testGhostServer(&requests, testGhostRoute{Status: tc.StatusCode, Body: tc.Body})
+
+		state := &terraform.InstanceState{
+			ID: "webhook_id",
+			Attributes: map[string]string{
+				"app_id":     "5accabf63d7eba00014e5679",
+				"module":     "wordpress",
+				"rev":        "master",
+				"commands.#": "1",
+				"commands.0": "deploy",
+				"etag":       "old_etag",
+			},
+		}
+		diff := &terraform.InstanceDiff{
+			Attributes: map[string]*terraform.ResourceAttrDiff{
+				"rev": {Old: "master", New: "develop"},
+			},
+		}
+
+		_, err := resourceGhostWebhook().Apply(state, diff, testGhostMeta(server.URL))
+		server.Close()
+
+		if err == nil || !strings.Contains(err.Error(), tc.ExpectedError) {
+			t.Fatalf("Unexpected error from resourceGhostWebhookUpdate with HTTP %d.\nExpected to contain: %s\nGiven: %v",
+				tc.StatusCode, tc.ExpectedError, err)
+		}
+		if ifMatch := requests[0].Header.Get("If-Match"); ifMatch != "old_etag" {
+			t.Fatalf("Unexpected If-Match header: %s", ifMatch)
+		}
+	}
+}